@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// change is the difference of a key between two environments
+type change struct {
+	Key string `json:"key"`
+	// Kind is `added`, `removed` or `changed`
+	Kind string `json:"kind"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// diff renders the effective configurations of two environments, and prints the keys added, removed
+// or changed between them, with secrets masked. The exit code is 1 when they differ, like diff(1)
+func diff(args []string, stdout, stderr io.Writer) int {
+	var (
+		options  loaderFlags
+		from, to string
+		format   string
+		secrets  string
+		flags    = flag.NewFlagSet("diff", flag.ContinueOnError)
+	)
+	flags.SetOutput(stderr)
+	flags.StringVar(&from, "from", "", "environment to compare from, e.g. staging")
+	flags.StringVar(&to, "to", "", "environment to compare to, e.g. production")
+	options.register(flags)
+	options.registerSchema(flags, "JSON Schema exported from the config struct, to name env vars after its fields like Load does")
+	flags.StringVar(&format, "format", "text", "output format, text or json")
+	flags.StringVar(&secrets, "secrets", "", "comma separated key names to mask, besides password, secret, token...")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: configor diff --from staging --to production [flags] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || from == "" || to == "" || (format != "text" && format != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	var leaves [2]map[string]string
+	for i, env := range []string{from, to} {
+		options.env = env
+		loader, err := options.configor()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		tree, err := loader.LoadTree(flags.Args()...)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		loader.ApplyENV(tree)
+		leaves[i] = flattenLeaves(tree, nil, map[string]string{})
+	}
+
+	changes := diffLeaves(leaves[0], leaves[1], splitList(secrets))
+	if format == "json" {
+		if changes == nil {
+			changes = []change{}
+		}
+		output, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		fmt.Fprintln(stdout, string(output))
+	} else {
+		fmt.Fprintf(stdout, "--- %v\n+++ %v\n", from, to)
+		for _, change := range changes {
+			switch change.Kind {
+			case "added":
+				fmt.Fprintf(stdout, "+ %v: %v\n", change.Key, change.To)
+			case "removed":
+				fmt.Fprintf(stdout, "- %v: %v\n", change.Key, change.From)
+			default:
+				fmt.Fprintf(stdout, "~ %v: %v -> %v\n", change.Key, change.From, change.To)
+			}
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// flattenLeaves collects the json encoded values of the leaves of tree by dotted key path
+func flattenLeaves(tree interface{}, keys []string, leaves map[string]string) map[string]string {
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		if len(node) > 0 {
+			for key, value := range node {
+				flattenLeaves(value, append(keys[:len(keys):len(keys)], key), leaves)
+			}
+			return leaves
+		}
+	case []interface{}:
+		if len(node) > 0 {
+			for i, value := range node {
+				flattenLeaves(value, append(keys[:len(keys):len(keys)], fmt.Sprint(i)), leaves)
+			}
+			return leaves
+		}
+	}
+
+	if len(keys) > 0 {
+		value, err := json.Marshal(tree)
+		if err != nil {
+			value = []byte(fmt.Sprint(tree))
+		}
+		leaves[strings.Join(keys, ".")] = string(value)
+	}
+	return leaves
+}
+
+// diffLeaves compares the leaves of two configurations, sorted by key.
+// Values of secret keys are compared, but masked in the result
+func diffLeaves(from, to map[string]string, secrets []string) []change {
+	keys := map[string]bool{}
+	for key := range from {
+		keys[key] = true
+	}
+	for key := range to {
+		keys[key] = true
+	}
+
+	var changes []change
+	for key := range keys {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		if inFrom && inTo && fromValue == toValue {
+			continue
+		}
+
+		if isSecret(key, secrets) {
+			fromValue, toValue = maskSecret(fromValue, inFrom), maskSecret(toValue, inTo)
+		}
+		switch {
+		case !inFrom:
+			changes = append(changes, change{Key: key, Kind: "added", To: toValue})
+		case !inTo:
+			changes = append(changes, change{Key: key, Kind: "removed", From: fromValue})
+		default:
+			changes = append(changes, change{Key: key, Kind: "changed", From: fromValue, To: toValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func maskSecret(value string, present bool) string {
+	if !present {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yml": `
+name: app
+db:
+  host: localhost
+  password: hunter2
+  pool: 10
+`,
+		"config.staging.yml": `
+db:
+  host: staging.example.org
+  password: staging
+credentials:
+  aws: AKIA-stg2
+debug: true
+`,
+		"config.production.yml": `
+db:
+  host: db.example.org
+  password: production
+  pool: 50
+credentials:
+  aws: AKIA-prod
+replicas:
+- replica0.example.org
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"diff", "--from", "staging", "--to", "production", "--format", "json", filepath.Join(dir, "config.yml")}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1 for different environments, but got %v: %v", code, stderr.String())
+	}
+
+	var changes []change
+	if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil {
+		t.Fatal(err)
+	}
+	expected := []change{
+		{Key: "credentials.aws", Kind: "changed", From: redacted, To: redacted},
+		{Key: "db.host", Kind: "changed", From: `"staging.example.org"`, To: `"db.example.org"`},
+		{Key: "db.password", Kind: "changed", From: redacted, To: redacted},
+		{Key: "db.pool", Kind: "changed", From: "10", To: "50"},
+		{Key: "debug", Kind: "removed", From: "true"},
+		{Key: "replicas.0", Kind: "added", To: `"replica0.example.org"`},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, changes)
+	}
+
+	stdout.Reset()
+	args = []string{"diff", "--from", "production", "--to", "production", filepath.Join(dir, "config.yml")}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for the same environment, but got %v: %v", code, stdout.String())
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// envdocProgram prints the env var reference of a config type, it's run from within
+// the module of the config package, as the type can only be inspected by a program importing it
+var envdocProgram = template.Must(template.New("envdoc").Parse(`// Code generated by configor envdoc. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/xmlking/configor"
+	target {{ printf "%q" .ImportPath }}
+)
+
+func main() {
+	loader := configor.New(&configor.Config{ENVPrefix: {{ printf "%q" .Prefix }}, Env: map[string]string{}})
+	if err := loader.WriteENVReference(os.Stdout, &target.{{ .Type }}{}, {{ printf "%q" .Format }}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// envdoc writes the reference of the env vars a config type is loaded from, e.g. from a
+// `//go:generate configor envdoc -type Config -o ENV.md` directive in the config package
+func envdoc(args []string, stdout, stderr io.Writer) int {
+	var (
+		typeName string
+		prefix   string
+		format   string
+		output   string
+		flags    = flag.NewFlagSet("envdoc", flag.ContinueOnError)
+	)
+	flags.SetOutput(stderr)
+	flags.StringVar(&typeName, "type", "", "config struct type name")
+	flags.StringVar(&prefix, "prefix", "", "ENV prefix of the application, defaults to Configor")
+	flags.StringVar(&format, "format", "markdown", "output format, markdown, csv or json")
+	flags.StringVar(&output, "o", "", "output file, defaults to stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: configor envdoc -type Config [flags] [package]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if typeName == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	pkg := "."
+	if flags.NArg() == 1 {
+		pkg = flags.Arg(0)
+	}
+	list, err := exec.Command("go", "list", "-f", "{{.ImportPath}} {{.Name}}", pkg).Output()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to find package %v: %v\n", pkg, err)
+		return 1
+	}
+	importPath, name, _ := strings.Cut(strings.TrimSpace(string(list)), " ")
+	if name == "main" {
+		fmt.Fprintf(stderr, "package %v is a main package, which can't be imported, move %v to another package\n", importPath, typeName)
+		return 1
+	}
+
+	// the program must be in the module of the package to import it
+	dir, err := ioutil.TempDir(".", ".configor-envdoc-")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	var program bytes.Buffer
+	envdocProgram.Execute(&program, map[string]string{"ImportPath": importPath, "Type": typeName, "Prefix": prefix, "Format": format})
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), program.Bytes(), 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	var reference bytes.Buffer
+	cmd := exec.Command("go", "run", "./"+filepath.Base(dir))
+	cmd.Stdout, cmd.Stderr = &reference, stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(stderr, "failed to generate env reference of %v.%v: %v\n", importPath, typeName, err)
+		return 1
+	}
+
+	if output == "" {
+		stdout.Write(reference.Bytes())
+	} else if err := ioutil.WriteFile(output, reference.Bytes(), 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
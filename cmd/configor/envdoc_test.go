@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnvdoc(t *testing.T) {
+	if testing.Short() {
+		t.Skip("envdoc compiles a program")
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"envdoc", "-type", "Config", "-prefix", "APP", "-format", "csv", "-o", file.Name(), "github.com/xmlking/configor"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, but got %v: %v", code, stderr.String())
+	}
+
+	reference, _ := ioutil.ReadFile(file.Name())
+	for _, row := range []string{"Name,Key,Type,Default,Required,Validate,Description", "APP_ENV_PREFIX,envprefix,string,,false,,"} {
+		if !strings.Contains(string(reference), row) {
+			t.Errorf("\nExpected reference to contain: %+v, \nGot: %+v", row, string(reference))
+		}
+	}
+
+	if code := run([]string{"envdoc", "-type", "Config", "github.com/xmlking/configor/cmd/configor"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for main packages, but got %v", code)
+	}
+}
@@ -0,0 +1,119 @@
+// Command configor inspects configuration files the way configor.Load reads them, e.g. in pre-deploy checks.
+//
+// Usage:
+//
+//	configor validate [--env production] [--schema schema.json] config.yml...
+//	configor render [--env production] [--env-file .env] [--schema schema.json] [--format yaml|json] [--explain] config.yml...
+//	configor diff --from staging --to production [--env-file .env] [--schema schema.json] config.yml...
+//	configor envdoc -type Config [--prefix APP] [--format markdown|csv|json] [-o ENV.md] [package]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/xmlking/configor"
+)
+
+const usage = `Usage: configor <command> [flags] [files...]
+
+Commands:
+  validate    check configuration files for syntax errors, and against a JSON Schema
+  render      print the effective configuration of an environment, with secrets redacted
+  diff        compare the effective configurations of two environments, key by key
+  envdoc      generate the reference of the env vars a config struct is loaded from
+
+Run 'configor <command> -h' for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command in args, and returns the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		return validate(args[1:], stdout, stderr)
+	case "render":
+		return render(args[1:], stdout, stderr)
+	case "diff":
+		return diff(args[1:], stdout, stderr)
+	case "envdoc":
+		return envdoc(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %v\n\n%v", args[0], usage)
+		return 2
+	}
+}
+
+// loaderFlags are the flags selecting how configuration files are resolved, shared by all commands
+type loaderFlags struct {
+	env      string
+	profiles string
+	sections bool
+	envFile  string
+	prefix   string
+	schema   string
+}
+
+// register adds the flags to flags, except the environment, which commands name differently
+func (loader *loaderFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&loader.sections, "sections", false, "files have top-level environment sections")
+	flags.StringVar(&loader.envFile, "env-file", "", "dotenv file of the env vars the application runs with")
+	flags.StringVar(&loader.prefix, "prefix", "", "ENV prefix of the application, defaults to CONFIGOR_ENV_PREFIX or Configor")
+}
+
+func (loader *loaderFlags) registerEnv(flags *flag.FlagSet) {
+	flags.StringVar(&loader.env, "env", "", "environment, defaults to CONFIGOR_ENV of the env file or development")
+	flags.StringVar(&loader.profiles, "profiles", "", "comma separated active profiles, e.g. production,eu-west")
+}
+
+// registerSchema adds the flag of the JSON Schema exported from the config struct, for the given use
+func (loader *loaderFlags) registerSchema(flags *flag.FlagSet, usage string) {
+	flags.StringVar(&loader.schema, "schema", "", usage)
+}
+
+// configor returns a Configor resolving files with the same rules as the application loading them.
+// Env vars are only read from the env file, never from the live environment, so results are reproducible
+func (loader *loaderFlags) configor() (*configor.Configor, error) {
+	config := &configor.Config{
+		Environment:         loader.env,
+		ENVPrefix:           loader.prefix,
+		EnvironmentSections: loader.sections,
+		Silent:              true,
+		Env:                 map[string]string{},
+	}
+	if loader.profiles != "" {
+		config.Profiles = strings.Split(loader.profiles, ",")
+	}
+	if loader.envFile != "" {
+		var err error
+		if config.Env, err = loadEnvFile(loader.envFile); err != nil {
+			return nil, err
+		}
+	}
+	if loader.schema != "" {
+		data, err := ioutil.ReadFile(loader.schema)
+		if err == nil {
+			err = json.Unmarshal(data, &config.Schema)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %v: %v", loader.schema, err)
+		}
+	}
+	return configor.New(config), nil
+}
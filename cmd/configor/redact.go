@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+)
+
+// redacted replaces the values of secret keys in output
+const redacted = "******"
+
+// secretKeys are the key name fragments values are considered secret for, matched case-insensitively
+var secretKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "privatekey", "private_key", "credential"}
+
+// isSecret reports whether the value of the dotted key path is secret, going by any of its segments,
+// so the leaves of `credentials.aws` are secret too
+func isSecret(key string, extra []string) bool {
+	for _, name := range strings.Split(strings.ToLower(key), ".") {
+		for _, secret := range append(secretKeys, extra...) {
+			if secret != "" && strings.Contains(name, strings.ToLower(secret)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redact returns a copy of tree with the values of secret keys replaced
+func redact(tree interface{}, keys []string, extra []string) interface{} {
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			path := append(keys[:len(keys):len(keys)], key)
+			if value != nil && isSecret(strings.Join(path, "."), extra) {
+				result[key] = redacted
+			} else {
+				result[key] = redact(value, path, extra)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(node))
+		for i, value := range node {
+			result[i] = redact(value, keys, extra)
+		}
+		return result
+	}
+	return tree
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/xmlking/configor"
+	"gopkg.in/yaml.v3"
+)
+
+// render prints the configuration an application gets in an environment: files and their overlays
+// merged, and overwritten by the env vars of the env file, with secrets redacted
+func render(args []string, stdout, stderr io.Writer) int {
+	var (
+		options loaderFlags
+		format  string
+		explain bool
+		secrets string
+		flags   = flag.NewFlagSet("render", flag.ContinueOnError)
+	)
+	flags.SetOutput(stderr)
+	options.registerEnv(flags)
+	options.register(flags)
+	options.registerSchema(flags, "JSON Schema exported from the config struct, to name env vars after its fields like Load does")
+	flags.StringVar(&format, "format", "yaml", "output format, yaml or json")
+	flags.BoolVar(&explain, "explain", false, "annotate each value with where it was loaded from, yaml format only")
+	flags.StringVar(&secrets, "secrets", "", "comma separated key names to redact, besides password, secret, token...")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: configor render [flags] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || (format != "yaml" && format != "json") || (explain && format != "yaml") {
+		flags.Usage()
+		return 2
+	}
+
+	loader, err := options.configor()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	tree, err := loader.LoadTree(flags.Args()...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	loader.ApplyENV(tree)
+	effective := redact(tree, nil, splitList(secrets))
+
+	var output []byte
+	if format == "json" {
+		if output, err = json.MarshalIndent(effective, "", "  "); err == nil {
+			output = append(output, '\n')
+		}
+	} else {
+		var sources map[string]configor.Origin
+		if explain {
+			sources = map[string]configor.Origin{}
+			for _, origin := range loader.Explain() {
+				sources[origin.Key] = origin
+			}
+		}
+		output, err = encodeYAML(effective, sources)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	stdout.Write(output)
+	return 0
+}
+
+// encodeYAML encodes tree with sorted keys, and the source of each value as a line comment if sources are given
+func encodeYAML(tree interface{}, sources map[string]configor.Origin) ([]byte, error) {
+	node, err := yamlNode(tree, nil, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), encoder.Close()
+}
+
+func yamlNode(tree interface{}, keys []string, sources map[string]configor.Origin) (*yaml.Node, error) {
+	switch value := tree.(type) {
+	case map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child, err := yamlNode(value[name], append(keys[:len(keys):len(keys)], name), sources)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, child)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i, item := range value {
+			child, err := yamlNode(item, append(keys[:len(keys):len(keys)], fmt.Sprint(i)), sources)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(tree); err != nil {
+		return nil, err
+	}
+	if origin, ok := sources[strings.ToLower(strings.Join(keys, "."))]; ok {
+		node.LineComment = origin.Source
+		if origin.Profile != "" {
+			node.LineComment += " (profile " + origin.Profile + ")"
+		}
+	}
+	return node, nil
+}
+
+// splitList splits a comma separated flag value, ignoring blanks
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
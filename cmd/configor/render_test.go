@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yml": `
+name: app
+db:
+  host: localhost
+  port: 5432
+  password: hunter2
+`,
+		"config.production.yml": `
+db:
+  host: db.example.org
+`,
+		".env": `
+# production env
+export CONFIGOR_DB_PORT=6543
+CONFIGOR_NAME="production app"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"render", "--env", "production", "--env-file", filepath.Join(dir, ".env"), "--explain", filepath.Join(dir, "config.yml")}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, but got %v: %v", code, stderr.String())
+	}
+
+	expected := strings.Join([]string{
+		"db:",
+		"  host: db.example.org # " + filepath.Join(dir, "config.production.yml") + " (profile production)",
+		"  password: '******' # " + filepath.Join(dir, "config.yml"),
+		"  port: 6543 # env CONFIGOR_DB_PORT",
+		"name: production app # env CONFIGOR_NAME",
+		"",
+	}, "\n")
+	if stdout.String() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	args = []string{"render", "--env", "development", "--format", "json", "--secrets", "host", filepath.Join(dir, "config.yml")}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, but got %v: %v", code, stderr.String())
+	}
+	for _, value := range []string{`"host": "******"`, `"password": "******"`, `"port": 5432`, `"name": "app"`} {
+		if !strings.Contains(stdout.String(), value) {
+			t.Errorf("\nExpected output to contain: %+v, \nGot: %+v", value, stdout.String())
+		}
+	}
+}
+
+func TestRenderSchema(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": `{
+  "type": "object",
+  "properties": {
+    "appname": {"type": "string", "x-go-name": "APPName"},
+    "maxsize": {"type": "integer", "x-go-name": "MaxSize"},
+    "token": {"type": "string", "x-go-name": "Token", "x-env": "API_TOKEN"}
+  }
+}`,
+		"config.yml": "appname: a\nmaxsize: 10\ntoken: abc\n",
+		".env":       "CONFIGOR_APP_NAME=b\nCONFIGOR_MAXSIZE=30\nAPI_TOKEN=xyz\n",
+	})
+	defer os.RemoveAll(dir)
+
+	// env vars are named after the fields of the schema, the way Load names them
+	var stdout, stderr bytes.Buffer
+	args := []string{"render", "--env-file", filepath.Join(dir, ".env"), "--schema", filepath.Join(dir, "schema.json"), "--explain", "--secrets", "none", filepath.Join(dir, "config.yml")}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, but got %v: %v", code, stderr.String())
+	}
+	expected := strings.Join([]string{
+		"appname: b # env CONFIGOR_APP_NAME",
+		"maxsize: 10 # " + filepath.Join(dir, "config.yml"),
+		"token: '******' # env API_TOKEN",
+		"",
+	}, "\n")
+	if stdout.String() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, stdout.String())
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/xmlking/configor"
+)
+
+// validate checks that files, with their overlays for the environment, can be decoded,
+// and that they match the JSON Schema of the config struct. All problems are reported at once
+func validate(args []string, stdout, stderr io.Writer) int {
+	var (
+		options loaderFlags
+		flags   = flag.NewFlagSet("validate", flag.ContinueOnError)
+	)
+	flags.SetOutput(stderr)
+	options.registerEnv(flags)
+	options.register(flags)
+	options.registerSchema(flags, "JSON Schema exported from the config struct, to check keys and values")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: configor validate [flags] files...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	loader, err := options.configor()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	schema := loader.Schema
+
+	var (
+		errs    []string
+		checked int
+	)
+	for _, arg := range flags.Args() {
+		files := loader.GetConfigurationFiles(arg)
+		if len(files) == 0 {
+			errs = append(errs, fmt.Sprintf("%v: no configuration files found", arg))
+		}
+
+		for _, file := range files {
+			checked++
+			if _, err := loader.LoadFileTree(file); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if schema != nil {
+				err := loader.ValidateFile(file, schema)
+				if schemaErrs, ok := err.(configor.SchemaErrors); ok {
+					for _, schemaErr := range schemaErrs {
+						errs = append(errs, schemaErr.Error())
+					}
+				} else if err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(stderr, err)
+		}
+		fmt.Fprintf(stderr, "%d problem(s) found in configuration for environment %v\n", len(errs), loader.GetEnvironment())
+		return 1
+	}
+	fmt.Fprintf(stdout, "%d file(s) valid for environment %v\n", checked, loader.GetEnvironment())
+	return 0
+}
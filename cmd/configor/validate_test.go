@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "$defs": {
+    "DB": {"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}, "additionalProperties": false}
+  },
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "db": {"$ref": "#/$defs/DB"},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "replicas": {"type": "array", "items": {"$ref": "#/$defs/DB"}}
+  },
+  "additionalProperties": false
+}`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": testSchema,
+		"config.yml": `
+name: app
+db:
+  host: localhost
+labels:
+  team: core
+replicas:
+- host: replica0
+`,
+		"config.production.yml": `
+db:
+  hots: db.example.org
+replicas:
+- prot: 5432
+`,
+		"config.staging.yml": "db: [host\n",
+	})
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"validate", "--env", "development", "--schema", filepath.Join(dir, "schema.json"), filepath.Join(dir, "config.yml")}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for valid configuration, but got %v: %v", code, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"validate", "--env", "production", "--schema", filepath.Join(dir, "schema.json"), filepath.Join(dir, "config.yml")}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for unknown keys, but got %v", code)
+	}
+	for _, problem := range []string{"config.production.yml:3:3: /db/hots: unknown key", "config.production.yml:5:3: /replicas/0/prot: unknown key", "2 problem(s) found"} {
+		if !strings.Contains(stderr.String(), problem) {
+			t.Errorf("\nExpected report to contain: %+v, \nGot: %+v", problem, stderr.String())
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"validate", "--profiles", "staging,production", filepath.Join(dir, "config.yml"), filepath.Join(dir, "missing.yml")}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for syntax errors, but got %v", code)
+	}
+	for _, problem := range []string{"config.staging.yml: yaml:", "missing.yml: no configuration files found"} {
+		if !strings.Contains(stderr.String(), problem) {
+			t.Errorf("\nExpected report to contain: %+v, \nGot: %+v", problem, stderr.String())
+		}
+	}
+}
+
+func TestValidateJSONKeys(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": testSchema,
+		"config.json": `{"Name": "app", "DB": {"Host": "localhost", "PORT": 5432}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	// json keys are matched case-insensitively, like encoding/json decodes them
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"validate", "--env", "development", "--schema", filepath.Join(dir, "schema.json"), filepath.Join(dir, "config.json")}, &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 for valid configuration, but got %v: %v", code, stderr.String())
+	}
+}
@@ -0,0 +1,195 @@
+package configor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configMapDataDir is the symlink kubelet atomically swaps when a mounted ConfigMap is updated
+const configMapDataDir = "..data"
+
+// getConfigMapKeys returns the keys of a mounted ConfigMap directory in lexical order,
+// skipping kubelet's internal `..data` / `..<timestamp>` entries
+func getConfigMapKeys(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+		// keys are symlinks into `..data`, so stat the target
+		if fileInfo, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil && fileInfo.Mode().IsRegular() {
+			keys = append(keys, entry.Name())
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// isConfigurationFile reports whether a ConfigMap key holds a whole configuration file
+func isConfigurationFile(key string) bool {
+	switch filepath.Ext(key) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// processConfigMap loads a mounted ConfigMap directory into config.
+// Keys with a known file extension are loaded as whole configuration files,
+// any other key is treated as a dotted field path, e.g. `db.port`
+func (configor *Configor) processConfigMap(config interface{}, dir string) error {
+	keys, err := getConfigMapKeys(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		file := filepath.Join(dir, key)
+		// ConfigMaps are mounted volumes, so never read them through pkger
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if isConfigurationFile(key) {
+			if configor.Config.Debug || configor.Config.Verbose {
+				fmt.Printf("Loading configurations from ConfigMap file '%v'...\n", file)
+			}
+			if err := configor.decode(config, file, data); err != nil {
+				return err
+			}
+			configor.recordOrigins(config, data, file, "", true)
+			continue
+		}
+
+		if configor.Config.Debug || configor.Config.Verbose {
+			fmt.Printf("Loading configuration for key `%v` from ConfigMap '%v'...\n", key, dir)
+		}
+		if err := configor.setFieldByPath(config, strings.Split(key, "."), strings.TrimSuffix(string(data), "\n")); err != nil {
+			return fmt.Errorf("failed to load ConfigMap key %v: %v", file, err)
+		}
+		configor.recordOrigin(key, file, "")
+	}
+	return nil
+}
+
+// getFieldKey returns the name a struct field is known by in configuration files
+func getFieldKey(fieldStruct *reflect.StructField) string {
+	for _, tag := range []string{"yaml", "json"} {
+		if name := strings.Split(fieldStruct.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(fieldStruct.Name)
+}
+
+// yamlFieldName returns the key yaml.v3 decodes a struct field from, which ignores json tags
+func yamlFieldName(fieldStruct *reflect.StructField) string {
+	if name := strings.Split(fieldStruct.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(fieldStruct.Name)
+}
+
+// jsonFieldName returns the key encoding/json decodes a struct field from, case-insensitively
+func jsonFieldName(fieldStruct *reflect.StructField) string {
+	if name := strings.Split(fieldStruct.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return fieldStruct.Name
+}
+
+// setFieldByPath walks config following the given field keys (matched case-insensitively)
+// and sets the field it ends on from value
+func (configor *Configor) setFieldByPath(config interface{}, path []string, value string) error {
+	field := reflect.ValueOf(config)
+	for _, key := range path {
+		for field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("field %v not found", key)
+		}
+
+		found := false
+		for i := 0; i < field.NumField(); i++ {
+			fieldStruct := field.Type().Field(i)
+			if field.Field(i).CanSet() && strings.EqualFold(getFieldKey(&fieldStruct), key) {
+				field, found = field.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("field %v not found", key)
+		}
+	}
+	return configor.setValue(field, value)
+}
+
+// getConfigMapVersion returns the target of the ConfigMap's `..data` symlink, which changes on every update
+func getConfigMapVersion(dir string) string {
+	version, _ := os.Readlink(filepath.Join(dir, configMapDataDir))
+	return version
+}
+
+// WatchConfigMaps polls the ConfigMap directories of the Configor every interval in the background,
+// and reloads configuration from files and ConfigMaps when kubelet swaps their `..data` symlink.
+// Every reload decodes into a new value of the type of config, which is left untouched, so keys removed
+// from ConfigMaps are dropped. onChange is called with the reloaded value, or nil and the error of the
+// reload, until stop is closed; swap it in under your own synchronization.
+// Explain keeps describing the Load made by the caller
+func (configor *Configor) WatchConfigMaps(config interface{}, interval time.Duration, stop <-chan struct{}, onChange func(config interface{}, err error), files ...string) {
+	configType := reflect.TypeOf(config).Elem()
+	versions := make(map[string]string, len(configor.ConfigMaps))
+	for _, dir := range configor.ConfigMaps {
+		versions[dir] = getConfigMapVersion(dir)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				changed := false
+				for _, dir := range configor.ConfigMaps {
+					if version := getConfigMapVersion(dir); version != versions[dir] {
+						versions[dir] = version
+						changed = true
+					}
+				}
+				if !changed {
+					continue
+				}
+
+				if configor.Config.Debug || configor.Config.Verbose {
+					fmt.Printf("ConfigMaps %v changed, reloading configuration\n", configor.ConfigMaps)
+				}
+				// a loader of its own, so the origins of the caller's Load are not written concurrently
+				reloaded := reflect.New(configType).Interface()
+				if err := (&Configor{Config: configor.Config}).Load(reloaded, files...); err != nil {
+					onChange(nil, err)
+				} else {
+					onChange(reloaded, nil)
+				}
+			}
+		}
+	}()
+}
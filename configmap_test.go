@@ -0,0 +1,144 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeConfigMap mimics the kubelet layout of a mounted ConfigMap:
+// keys are symlinks into `..data`, which itself links to a timestamped directory
+func writeConfigMap(t *testing.T, dir, version string, data map[string]string) {
+	versionDir := filepath.Join(dir, version)
+	if err := os.Mkdir(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range data {
+		if err := ioutil.WriteFile(filepath.Join(versionDir, key), []byte(value), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(filepath.Join(dir, key)); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join(configMapDataDir, key), filepath.Join(dir, key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// atomically swap `..data` like kubelet does
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(version, tmpLink); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(dir, configMapDataDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type configMapConfig struct {
+	APPName string
+	Hosts   []string
+	DB      *Database
+}
+
+func TestLoadConfigMap(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfigMap(t, dir, "..2020_08_17_00_00_00.000000001", map[string]string{
+		"config.yaml": "appname: configmap\nhosts:\n- http://example.org\n",
+		"db.password": "secret\n",
+		"db.port":     "5432",
+	})
+
+	var result configMapConfig
+	if err := New(&Config{ConfigMaps: []string{dir}}).Load(&result); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := configMapConfig{
+		APPName: "configmap",
+		Hosts:   []string{"http://example.org"},
+		DB:      &Database{User: "root", Password: "secret", Port: 5432, SSL: true},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestLoadConfigMapUnknownKey(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfigMap(t, dir, "..2020_08_17_00_00_00.000000001", map[string]string{
+		"db.prot": "5432",
+	})
+
+	var result configMapConfig
+	if err := New(&Config{ConfigMaps: []string{dir}}).Load(&result); err == nil {
+		t.Errorf("Should get error when loading ConfigMap key that matches no field")
+	}
+}
+
+func TestWatchConfigMaps(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeConfigMap(t, dir, "..2020_08_17_00_00_00.000000001", map[string]string{
+		"appname":     "v1",
+		"db.password": "secret",
+		"hosts":       "[a, b]",
+	})
+
+	var result configMapConfig
+	configor := New(&Config{ConfigMaps: []string{dir}})
+	if err := configor.Load(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	type reload struct {
+		config interface{}
+		err    error
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	reloaded := make(chan reload, 1)
+	configor.WatchConfigMaps(&result, 10*time.Millisecond, stop, func(config interface{}, err error) {
+		select {
+		case reloaded <- reload{config, err}:
+		case <-stop:
+		}
+	})
+
+	writeConfigMap(t, dir, "..2020_08_17_00_00_00.000000002", map[string]string{
+		"appname":     "v2",
+		"db.password": "secret",
+	})
+
+	select {
+	case reload := <-reloaded:
+		if reload.err != nil {
+			t.Fatal(reload.err)
+		}
+		expected := &configMapConfig{APPName: "v2", DB: &Database{User: "root", Password: "secret", Port: 3306, SSL: true}}
+		if !reflect.DeepEqual(reload.config, expected) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", expected, reload.config)
+		}
+		if result.APPName != "v1" || len(result.Hosts) != 2 {
+			t.Errorf("The loaded config should be left untouched, instead got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Error("ConfigMap update should trigger a reload")
+	}
+}
@@ -5,10 +5,18 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"strings"
 )
 
 type Configor struct {
 	*Config
+
+	// where each configuration key was loaded from by the last Load
+	origins map[string]Origin
+	// env var names fields were looked up with by the last Load, and name prefixes of the entries
+	// of primitive maps and slices, true for slices, whose entries take only indexes
+	knownENVs        map[string]bool
+	knownENVPrefixes map[string]bool
 }
 
 type Config struct {
@@ -19,10 +27,50 @@ type Config struct {
 	Silent      bool
 	UsePkger    bool
 
+	// Map struct field paths to env var names, tried in priority order. Defaults to UpperSnakeCaseMapper
+	ENVNameMappers []NameMapper
+	// Separator between the ENV prefix, field names and slice indexes in env var names. Defaults to `_`
+	ENVSeparator string
+
+	// Snapshot of env vars to load from instead of the process env, e.g. to run tests in parallel
+	Env map[string]string
+
+	// Warn about env vars starting with the ENV prefix that don't match any field, e.g. misspelled ones
+	WarnOnUnknownENVs bool
+	// Return an error instead of warning about env vars that don't match any field
+	ErrorOnUnknownENVs bool
+
+	// Decode env, ConfigMap, yaml file and `default` tag values of custom types, tried in order before encoding.TextUnmarshaler
+	DecodeHooks []DecodeHook
+
+	// Active profiles, e.g. production, eu-west, canary. Their overlay files are loaded in order
+	Profiles []string
+
 	// In case of json files, this field will be used only when compiled with
 	// go 1.10 or later.
 	// This field will be ignored when compiled with go versions lower than 1.10.
 	ErrorOnUnmatchedKeys bool
+
+	// Mounted Kubernetes ConfigMap directories, loaded after configuration files
+	ConfigMaps []string
+
+	// Detect the environment when neither Environment nor CONFIGOR_ENV is set, first match wins
+	EnvironmentDetectors []EnvironmentDetector
+
+	// Maps environment names to canonical ones, e.g. `prod` to `production`
+	EnvironmentAliases map[string]string
+
+	// Known environment names, Load returns an error for any other environment
+	Environments []string
+
+	// Validate each configuration file against this JSON Schema before decoding it
+	Schema *Schema
+	// Validate each configuration file against the JSON Schema of the config struct, when Schema is not set
+	ValidateSchema bool
+
+	// Configuration files have top-level `default`, `development`, `production`... sections,
+	// and only the `default` section and the one of the current environment are loaded
+	EnvironmentSections bool
 }
 
 // New initialize a Configor
@@ -31,22 +79,26 @@ func New(config *Config) *Configor {
 		config = &Config{}
 	}
 
-	if os.Getenv("CONFIGOR_DEBUG_MODE") != "" {
+	if config.getenv("CONFIGOR_DEBUG_MODE") != "" {
 		config.Debug = true
 	}
 
-	if os.Getenv("CONFIGOR_VERBOSE_MODE") != "" {
+	if config.getenv("CONFIGOR_VERBOSE_MODE") != "" {
 		config.Verbose = true
 	}
 
-	if os.Getenv("CONFIGOR_SILENT_MODE") != "" {
+	if config.getenv("CONFIGOR_SILENT_MODE") != "" {
 		config.Silent = true
 	}
 
-	if os.Getenv("CONFIGOR_USE_PKGER") != "" {
+	if config.getenv("CONFIGOR_USE_PKGER") != "" {
 		config.UsePkger = true
 	}
 
+	if config.getenv("CONFIGOR_ENVIRONMENT_SECTIONS") != "" {
+		config.EnvironmentSections = true
+	}
+
 	return &Configor{Config: config}
 }
 
@@ -54,11 +106,25 @@ var testRegexp = regexp.MustCompile("_test|(\\.test$)")
 
 // GetEnvironment get environment
 func (configor *Configor) GetEnvironment() string {
+	return configor.resolveEnvironmentAlias(configor.detectEnvironment())
+}
+
+func (configor *Configor) detectEnvironment() string {
 	if configor.Environment == "" {
-		if env := os.Getenv("CONFIGOR_ENV"); env != "" {
+		if env := configor.getenv("CONFIGOR_ENV"); env != "" {
 			return env
 		}
 
+		for _, detector := range configor.EnvironmentDetectors {
+			if env := configor.detect(detector); env != "" {
+				return env
+			}
+		}
+
+		if profiles := configor.getProfiles(); len(profiles) > 0 {
+			return profiles[0]
+		}
+
 		if testRegexp.MatchString(os.Args[0]) {
 			return "test"
 		}
@@ -68,6 +134,41 @@ func (configor *Configor) GetEnvironment() string {
 	return configor.Environment
 }
 
+func (configor *Configor) getProfiles() []string {
+	profiles := configor.Profiles
+	// like CONFIGOR_ENV, CONFIGOR_PROFILES doesn't override an explicit Environment
+	if len(profiles) == 0 && configor.Environment == "" {
+		if env := configor.getenv("CONFIGOR_PROFILES"); env != "" {
+			profiles = strings.Split(env, ",")
+		}
+	}
+
+	result := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			result = append(result, configor.resolveEnvironmentAlias(profile))
+		}
+	}
+	return result
+}
+
+// GetProfiles returns the active profiles, set by config or with CONFIGOR_PROFILES, e.g. `production,eu-west,canary`.
+// CONFIGOR_PROFILES is ignored when Environment is set.
+// The environment is always active, first unless it is one of the profiles, so `CONFIGOR_ENV=production` with
+// `CONFIGOR_PROFILES=eu-west` loads `config.production.yml` and then `config.eu-west.yml`
+func (configor *Configor) GetProfiles() []string {
+	var (
+		environment = configor.GetEnvironment()
+		profiles    = configor.getProfiles()
+	)
+	for _, profile := range profiles {
+		if profile == environment {
+			return profiles
+		}
+	}
+	return append([]string{environment}, profiles...)
+}
+
 // GetErrorOnUnmatchedKeys returns a boolean indicating if an error should be
 // thrown if there are keys in the config file that do not correspond to the
 // config struct
@@ -81,6 +182,9 @@ func (configor *Configor) Load(config interface{}, files ...string) (err error)
 	if !defaultValue.CanAddr() {
 		return fmt.Errorf("Config %v should be addressable", config)
 	}
+	if err = configor.checkEnvironment(); err != nil {
+		return err
+	}
 	err = configor.load(config, files...)
 	return
 }
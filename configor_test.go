@@ -2,14 +2,16 @@ package configor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 type Anonymous struct {
@@ -113,8 +115,10 @@ func TestDefaultValue(t *testing.T) {
 }
 
 func TestMissingRequiredValue(t *testing.T) {
-	config := generateDefaultConfig()
-	config.DB.Password = ""
+	var config map[string]interface{}
+	bytes, _ := json.Marshal(generateDefaultConfig())
+	json.Unmarshal(bytes, &config)
+	delete(config["DB"].(map[string]interface{}), "Password")
 
 	if bytes, err := json.Marshal(config); err == nil {
 		if file, err := ioutil.TempFile("/tmp", "configor"); err == nil {
@@ -166,10 +170,11 @@ func TestUnmatchedKeyInYamltestConfigFile(t *testing.T) {
 		if err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&result, filename); err == nil {
 			t.Errorf("Should get error when loading configuration with extra keys")
 
-			// The error should be of type *yaml.TypeError
-		} else if _, ok := err.(*yaml.TypeError); !ok {
-			// || !strings.Contains(err.Error(), "not found in struct") {
-			t.Errorf("Error should be of type yaml.TypeError. Instead error is %v", err)
+			// The error should wrap a *yaml.TypeError, located in the file
+		} else if typeErr := new(yaml.TypeError); !errors.As(err, &typeErr) {
+			t.Errorf("Error should wrap a yaml.TypeError. Instead error is %v", err)
+		} else if !strings.HasPrefix(err.Error(), filename+":2:1: test: ") {
+			t.Errorf("Error should be located at the unmatched key. Instead error is %v", err)
 		}
 
 	} else {
@@ -195,10 +200,11 @@ func TestUnmatchedKeyInYamltestConfigFile(t *testing.T) {
 	if err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&result, filename); err == nil {
 		t.Errorf("Should get error when loading configuration with extra keys")
 
-		// The error should be of type *yaml.TypeError
-	} else if _, ok := err.(*yaml.TypeError); !ok {
-		// || !strings.Contains(err.Error(), "not found in struct") {
-		t.Errorf("Error should be of type yaml.TypeError. Instead error is %v", err)
+		// The error should wrap a *yaml.TypeError, located in the file
+	} else if typeErr := new(yaml.TypeError); !errors.As(err, &typeErr) {
+		t.Errorf("Error should wrap a yaml.TypeError. Instead error is %v", err)
+	} else if !strings.HasPrefix(err.Error(), filename+":2:1: test: ") {
+		t.Errorf("Error should be located at the unmatched key. Instead error is %v", err)
 	}
 }
 
@@ -658,3 +664,78 @@ func TestUsePkger(t *testing.T) {
 		t.Errorf("failed to marshal config")
 	}
 }
+
+func TestLoadConfigurationDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/00-app.yaml", []byte("appname: app\nhosts:\n- http://example.org\n"), 0644)
+	ioutil.WriteFile(dir+"/10-db.yaml", []byte("db:\n  name: db\n  password: secret\n"), 0644)
+	ioutil.WriteFile(dir+"/10-db.test.yaml", []byte("db:\n  name: test_db\n  password: secret\n"), 0644)
+	ioutil.WriteFile(dir+"/20-cache.production.yaml", []byte("appname: production\n"), 0644)
+	ioutil.WriteFile(dir+"/30-queue.example.yaml", []byte("appname: example\n"), 0644)
+	ioutil.WriteFile(dir+"/README.md", []byte("not a configuration file"), 0644)
+
+	type config struct {
+		APPName string
+		Hosts   []string
+		DB      Database
+	}
+	expected := config{
+		APPName: "app",
+		Hosts:   []string{"http://example.org"},
+		DB:      Database{Name: "test_db", User: "root", Password: "secret", Port: 3306, SSL: true},
+	}
+
+	for _, file := range []string{dir, dir + "/*.yaml"} {
+		var result config
+		if err := Load(&result, file); err != nil {
+			t.Errorf("No error should happen when load configurations from %v, but got %v", file, err)
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+		}
+	}
+}
+
+func TestLoadEnvironmentSections(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	defer os.Remove(file.Name())
+	file.WriteString(`
+default:
+  appname: configor
+  db:
+    name: configor
+    password: configor
+production:
+  db:
+    name: production_db
+development:
+  appname: development
+`)
+
+	type config struct {
+		APPName string
+		DB      Database
+	}
+
+	var result config
+	if err := New(&Config{Environment: "production", EnvironmentSections: true}).Load(&result, file.Name()); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		APPName: "configor",
+		DB:      Database{Name: "production_db", User: "root", Password: "configor", Port: 3306, SSL: true},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
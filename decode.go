@@ -0,0 +1,297 @@
+package configor
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeHook decodes the string representation of a value of type typ, as found in shell env,
+// a ConfigMap key, a scalar of a yaml configuration file or a `default` tag.
+// It returns a nil value for types it does not handle
+type DecodeHook func(value string, typ reflect.Type) (interface{}, error)
+
+var urlType = reflect.TypeOf(url.URL{})
+
+// URLDecodeHook decodes `url.URL` values, which don't implement encoding.TextUnmarshaler
+func URLDecodeHook(value string, typ reflect.Type) (interface{}, error) {
+	if typ != urlType {
+		return nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return *u, nil
+}
+
+// defaultDecodeHooks are tried after the hooks of Config
+var defaultDecodeHooks = []DecodeHook{URLDecodeHook}
+
+// runDecodeHooks returns the value of type typ the first decode hook handling it decodes from value, if any
+func (configor *Configor) runDecodeHooks(value string, typ reflect.Type) (interface{}, error) {
+	for _, hook := range append(configor.DecodeHooks, defaultDecodeHooks...) {
+		if result, err := hook(value, typ); err != nil || result != nil {
+			return result, err
+		}
+	}
+	return nil, nil
+}
+
+// setHookResult sets field to the result of a decode hook
+func setHookResult(field reflect.Value, result interface{}) error {
+	resultValue := reflect.ValueOf(result)
+	if !resultValue.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("decode hook returned %v for %v", resultValue.Type(), field.Type())
+	}
+	field.Set(resultValue.Convert(field.Type()))
+	return nil
+}
+
+// setValue sets field from its string representation, as found in shell env
+// or in a ConfigMap key. Decode hooks are tried first, then encoding.TextUnmarshaler
+// and json.Unmarshaler implementations, and finally yaml decoding
+func (configor *Configor) setValue(field reflect.Value, value string) error {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+
+	if result, err := configor.runDecodeHooks(value, field.Type()); err != nil || result != nil {
+		if err != nil {
+			return err
+		}
+		return setHookResult(field, result)
+	}
+
+	if optional, ok := field.Addr().Interface().(interface{ setOptional() reflect.Value }); ok {
+		return configor.setValue(optional.setOptional(), value)
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(json.Unmarshaler); ok {
+		// value may be a json document, or a bare string
+		if err := unmarshaler.UnmarshalJSON([]byte(value)); err == nil {
+			return nil
+		}
+		return unmarshaler.UnmarshalJSON([]byte(strconv.Quote(value)))
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "", "0", "f", "false":
+			field.SetBool(false)
+		default:
+			field.SetBool(true)
+		}
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	return nil
+}
+
+// hookedScalars are the scalars of a yaml node decode hooks decoded, set after the node is decoded
+type hookedScalars map[*yaml.Node]interface{}
+
+// runNodeDecodeHooks runs decode hooks on the scalars of node decoded into typ, and blanks the
+// scalars they decode, so yaml or json decoding leaves their fields to setHookedScalars.
+// jsonFile matches the keys of node to fields like json decoding
+func (configor *Configor) runNodeDecodeHooks(file string, node *yaml.Node, typ reflect.Type, keys []string, jsonFile bool, hooked hookedScalars, errs *FileErrors) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			configor.runNodeDecodeHooks(file, content, typ, keys, jsonFile, hooked, errs)
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return
+		}
+		result, err := configor.runDecodeHooks(node.Value, typ)
+		if err != nil {
+			errs.Errors = append(errs.Errors, FileError{File: file, Line: node.Line, Column: node.Column, Key: strings.Join(keys, "."), Message: err.Error()})
+		} else if result != nil {
+			hooked[node] = result
+			*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: node.Line, Column: node.Column}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				continue
+			}
+			if elemType, ok := nodeElemType(typ, key.Value, jsonFile); ok {
+				configor.runNodeDecodeHooks(file, value, elemType, append(keys[:len(keys):len(keys)], key.Value), jsonFile, hooked, errs)
+			}
+		}
+	case yaml.SequenceNode:
+		if typ.Kind() == reflect.Slice {
+			for i, item := range node.Content {
+				configor.runNodeDecodeHooks(file, item, typ.Elem(), append(keys[:len(keys):len(keys)], strconv.Itoa(i)), jsonFile, hooked, errs)
+			}
+		}
+	}
+}
+
+// setHookedScalars sets the fields of value the hooked scalars of node are decoded into
+func setHookedScalars(node *yaml.Node, value reflect.Value, jsonFile bool, hooked hookedScalars) error {
+	if result, ok := hooked[node]; ok {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		return setHookResult(value, result)
+	}
+
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			if err := setHookedScalars(content, value, jsonFile, hooked); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, elem := node.Content[i], node.Content[i+1]
+			switch value.Kind() {
+			case reflect.Struct:
+				if index, ok := fileFieldIndex(value.Type(), key.Value, jsonFile); ok {
+					if err := setHookedScalars(elem, value.FieldByIndex(index), jsonFile, hooked); err != nil {
+						return err
+					}
+				}
+			case reflect.Map:
+				// map values are not addressable, so copy them and store them back
+				mapKey := reflect.New(value.Type().Key())
+				if err := key.Decode(mapKey.Interface()); err != nil || value.IsNil() || !value.MapIndex(mapKey.Elem()).IsValid() {
+					continue
+				}
+				mapValue := reflect.New(value.Type().Elem()).Elem()
+				mapValue.Set(value.MapIndex(mapKey.Elem()))
+				if err := setHookedScalars(elem, mapValue, jsonFile, hooked); err != nil {
+					return err
+				}
+				value.SetMapIndex(mapKey.Elem(), mapValue)
+			}
+		}
+	case yaml.SequenceNode:
+		if value.Kind() != reflect.Slice {
+			return nil
+		}
+		if jsonFile {
+			// json decoding keeps null items of arrays
+			for i := 0; i < len(node.Content) && i < value.Len(); i++ {
+				if err := setHookedScalars(node.Content[i], value.Index(i), jsonFile, hooked); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// yaml decoding drops null items of sequences, hooked scalars included, so rebuild the slice
+		elems := reflect.MakeSlice(value.Type(), 0, len(node.Content))
+		for i, j := 0, 0; i < len(node.Content); i++ {
+			item := node.Content[i]
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if _, ok := hooked[item]; !ok {
+				if item.ShortTag() == "!!null" && !isNullable(elem.Kind()) {
+					continue
+				}
+				if j >= value.Len() {
+					break
+				}
+				elem.Set(value.Index(j))
+				j++
+			}
+			if err := setHookedScalars(item, elem, jsonFile, hooked); err != nil {
+				return err
+			}
+			elems = reflect.Append(elems, elem)
+		}
+		value.Set(elems)
+	}
+	return nil
+}
+
+// isNullable reports whether yaml decodes null into values of kind, rather than leaving them unchanged
+func isNullable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// nodeElemType returns the type the value of key of a yaml or json mapping is decoded into, for a struct or map typ
+func nodeElemType(typ reflect.Type, key string, jsonFile bool) (reflect.Type, bool) {
+	switch typ.Kind() {
+	case reflect.Map:
+		return typ.Elem(), true
+	case reflect.Struct:
+		if index, ok := fileFieldIndex(typ, key, jsonFile); ok {
+			return typ.FieldByIndex(index).Type, true
+		}
+	}
+	return nil, false
+}
+
+// fileFieldIndex returns the index of the field of struct typ key of a yaml or json file is decoded into
+func fileFieldIndex(typ reflect.Type, key string, jsonFile bool) ([]int, bool) {
+	if jsonFile {
+		return jsonFieldIndex(typ, key)
+	}
+	return yamlFieldIndex(typ, key)
+}
+
+// yamlFieldIndex returns the index of the field of struct typ yaml decodes key into: the field named
+// by its `yaml` tag, or else its lowercased name, looking into `,inline` structs
+func yamlFieldIndex(typ reflect.Type, key string) ([]int, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		fieldStruct := typ.Field(i)
+		if !fieldStruct.IsExported() && !fieldStruct.Anonymous {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(fieldStruct.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(flags, "inline") {
+			if fieldStruct.Type.Kind() == reflect.Struct {
+				if index, ok := yamlFieldIndex(fieldStruct.Type, key); ok {
+					return append([]int{i}, index...), true
+				}
+			}
+			continue
+		}
+		if yamlFieldName(&fieldStruct) == key {
+			return []int{i}, true
+		}
+	}
+	return nil, false
+}
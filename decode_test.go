@@ -0,0 +1,155 @@
+package configor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %v", string(text))
+	}
+	return nil
+}
+
+type celsius float64
+
+func celsiusHook(value string, typ reflect.Type) (interface{}, error) {
+	if typ != reflect.TypeOf(celsius(0)) {
+		return nil, nil
+	}
+	var c float64
+	_, err := fmt.Sscanf(value, "%fC", &c)
+	return c, err
+}
+
+func TestDecodeENVValues(t *testing.T) {
+	type config struct {
+		Timeout     time.Duration
+		IP          net.IP
+		Endpoint    *url.URL
+		Pattern     *regexp.Regexp
+		Total       big.Int
+		Level       level
+		Temperature celsius
+	}
+
+	envs := map[string]string{
+		"DECODE_TIMEOUT":     "1m30s",
+		"DECODE_IP":          "10.0.0.1",
+		"DECODE_ENDPOINT":    "https://example.org/api",
+		"DECODE_PATTERN":     "^v[0-9]+$",
+		"DECODE_TOTAL":       "123456789012345678901234567890",
+		"DECODE_LEVEL":       "INFO",
+		"DECODE_TEMPERATURE": "21.5C",
+	}
+	for name, value := range envs {
+		os.Setenv(name, value)
+		defer os.Setenv(name, "")
+	}
+
+	var result config
+	if err := New(&Config{ENVPrefix: "DECODE", DecodeHooks: []DecodeHook{celsiusHook}}).Load(&result); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	total, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	switch {
+	case result.Timeout != 90*time.Second:
+		t.Errorf("Failed to load duration from env, got %v", result.Timeout)
+	case !result.IP.Equal(net.ParseIP("10.0.0.1")):
+		t.Errorf("Failed to load IP from env, got %v", result.IP)
+	case result.Endpoint == nil || result.Endpoint.Host != "example.org":
+		t.Errorf("Failed to load URL from env, got %v", result.Endpoint)
+	case result.Pattern == nil || !result.Pattern.MatchString("v2"):
+		t.Errorf("Failed to load regexp from env, got %v", result.Pattern)
+	case result.Total.Cmp(total) != 0:
+		t.Errorf("Failed to load big.Int from env, got %v", result.Total.String())
+	case result.Level != 1:
+		t.Errorf("Failed to load enum from env, got %v", result.Level)
+	case result.Temperature != 21.5:
+		t.Errorf("Failed to load value with decode hook from env, got %v", result.Temperature)
+	}
+}
+
+func TestDecodeHooksFileAndDefaults(t *testing.T) {
+	type room struct {
+		Temperature *celsius
+	}
+	type config struct {
+		Temperature celsius
+		Minimum     celsius `default:"-5C"`
+		Readings    []celsius
+		Rooms       map[string]room
+		Endpoint    url.URL
+		Name        string
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("temperature: 21.5C\nreadings:\n- 20C\n- 22.5C\nrooms:\n  kitchen:\n    temperature: 19C\nendpoint: https://example.org/api\nname: 21C\n")
+	file.Close()
+
+	jsonFile, err := ioutil.TempFile("/tmp", "configor.*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(jsonFile.Name())
+	jsonFile.WriteString(`{"Temperature": "21.5C", "Readings": ["20C", "22.5C"], "Rooms": {"kitchen": {"Temperature": "19C"}}, "Endpoint": "https://example.org/api", "Name": "21C"}`)
+	jsonFile.Close()
+
+	var result config
+	for _, test := range []struct {
+		file   string
+		strict bool
+	}{{file.Name(), false}, {file.Name(), true}, {jsonFile.Name(), false}, {jsonFile.Name(), true}} {
+		result = config{}
+		if err := New(&Config{DecodeHooks: []DecodeHook{celsiusHook}, ErrorOnUnmatchedKeys: test.strict}).Load(&result, test.file); err != nil {
+			t.Fatalf("No error should happen when load configurations, but got %v", err)
+		}
+
+		kitchen := celsius(19)
+		expected := config{
+			Temperature: 21.5,
+			Minimum:     -5,
+			Readings:    []celsius{20, 22.5},
+			Rooms:       map[string]room{"kitchen": {Temperature: &kitchen}},
+			Endpoint:    url.URL{Scheme: "https", Host: "example.org", Path: "/api"},
+			Name:        "21C",
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+		}
+	}
+
+	ioutil.WriteFile(file.Name(), []byte("name: configor\ntemperature: hot\n"), 0644)
+	err = New(&Config{DecodeHooks: []DecodeHook{celsiusHook}}).Load(&result, file.Name())
+	if expected := file.Name() + ":2:14: temperature: strconv.ParseFloat: parsing \"\": invalid syntax"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+
+	ioutil.WriteFile(jsonFile.Name(), []byte("{\n  \"Name\": \"configor\",\n  \"Temperature\": \"hot\"\n}\n"), 0644)
+	err = New(&Config{DecodeHooks: []DecodeHook{celsiusHook}}).Load(&result, jsonFile.Name())
+	if expected := jsonFile.Name() + ":3:18: Temperature: strconv.ParseFloat: parsing \"\": invalid syntax"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+}
@@ -0,0 +1,461 @@
+package configor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/creasty/defaults"
+)
+
+// Defaulter is implemented by config structs with defaults that can't be expressed in `default` tags,
+// e.g. computed from the CPU count. It is creasty/defaults' Setter, so SetDefaults is called after the
+// tag defaults of the struct are applied, and can override them
+type Defaulter = defaults.Setter
+
+// setDefaults applies `default` tags to config with creasty/defaults, extended to default expressions,
+// unit types, decode hooks, map entries and map values, and records the keys changed by SetDefaults methods
+// as set by `default (method)`. root is the top-level config, which `@Field.Path` expressions refer to,
+// and keys the key path of config
+func (configor *Configor) setDefaults(root, config interface{}, keys []string) error {
+	unresolved, err := configor.setExpressionDefaults(reflect.ValueOf(root), reflect.ValueOf(config))
+	if err != nil {
+		return err
+	}
+	if err := configor.setTextDefaults(reflect.ValueOf(config)); err != nil {
+		return err
+	}
+	if err := configor.setMapDefaults(root, reflect.ValueOf(config), keys); err != nil {
+		return err
+	}
+
+	before := flattenValue(reflect.ValueOf(config), keys)
+	if err := defaults.Set(config); err != nil {
+		return err
+	}
+
+	// creasty/defaults sets the expression itself to string fields it resolved blank for
+	for _, field := range unresolved {
+		if field.Kind() == reflect.String && isDefaultExpression(field.String()) {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+
+	tagged := tagDefaultValues(reflect.ValueOf(config), keys)
+	for key, after := range flattenValue(reflect.ValueOf(config), keys) {
+		if previous, ok := before[key]; ok && previous == after {
+			continue
+		}
+		if value, ok := tagged[key]; !ok || value != after {
+			configor.recordOrigin(key, "default (method)", "")
+		}
+	}
+	return nil
+}
+
+// isDefaultExpression reports whether a `default` tag is an expression, e.g. `${HOSTNAME}`,
+// `@DB.Host` or `{{ .Env }}-bucket`, rather than a literal value. A leading backslash escapes an expression
+func isDefaultExpression(defaultValue string) bool {
+	if strings.HasPrefix(defaultValue, `\`) {
+		return false
+	}
+	return strings.HasPrefix(defaultValue, "@") || strings.Contains(defaultValue, "${") || strings.Contains(defaultValue, "{{")
+}
+
+// literalDefault returns the value of a literal `default` tag, without the backslash escaping an expression,
+// e.g. `\@home` is `@home`
+func literalDefault(defaultValue string) string {
+	if strings.HasPrefix(defaultValue, `\`) && isDefaultExpression(defaultValue[1:]) {
+		return defaultValue[1:]
+	}
+	return defaultValue
+}
+
+// defaultTemplateData is the data `{{ }}` default expressions are executed with
+type defaultTemplateData struct {
+	Env      string
+	Profiles []string
+}
+
+// resolveDefaultExpression evaluates a default expression. `@Field.Path` copies the value of another
+// field of root, templates are executed against the active environment, and `${NAME}` is expanded from env
+func (configor *Configor) resolveDefaultExpression(root reflect.Value, expression string, depth int) (string, error) {
+	if depth > 10 {
+		return "", fmt.Errorf("default expression %v refers to itself", expression)
+	}
+
+	if strings.HasPrefix(expression, "@") {
+		field, fieldStruct, err := getFieldByPath(root, strings.TrimPrefix(expression, "@"))
+		if err != nil {
+			return "", fmt.Errorf("invalid default expression %v: %v", expression, err)
+		}
+		if field.IsZero() && fieldStruct.Tag.Get("default") != "" {
+			// the referenced field may not have its own default applied yet
+			if defaultValue := fieldStruct.Tag.Get("default"); isDefaultExpression(defaultValue) {
+				return configor.resolveDefaultExpression(root, defaultValue, depth+1)
+			}
+			return literalDefault(fieldStruct.Tag.Get("default")), nil
+		}
+		if field.IsZero() {
+			return "", nil
+		}
+		return fmt.Sprint(reflect.Indirect(field).Interface()), nil
+	}
+
+	if strings.Contains(expression, "{{") {
+		tmpl, err := template.New("default").Funcs(template.FuncMap{"env": configor.getenv}).Parse(expression)
+		if err != nil {
+			return "", fmt.Errorf("invalid default expression %v: %v", expression, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, defaultTemplateData{Env: configor.GetEnvironment(), Profiles: configor.GetProfiles()}); err != nil {
+			return "", fmt.Errorf("invalid default expression %v: %v", expression, err)
+		}
+		expression = buf.String()
+	}
+
+	return os.Expand(expression, configor.getenv), nil
+}
+
+// getFieldByPath returns the field of root at a dotted path of field names, e.g. `DB.Host`
+func getFieldByPath(root reflect.Value, path string) (reflect.Value, reflect.StructField, error) {
+	var (
+		field       = root
+		fieldStruct reflect.StructField
+	)
+	for _, name := range strings.Split(path, ".") {
+		field = reflect.Indirect(field)
+		if field.Kind() != reflect.Struct {
+			return reflect.Value{}, fieldStruct, fmt.Errorf("field %v not found", path)
+		}
+		var ok bool
+		if fieldStruct, ok = field.Type().FieldByName(name); !ok {
+			return reflect.Value{}, fieldStruct, fmt.Errorf("field %v not found", path)
+		}
+		field = field.FieldByIndex(fieldStruct.Index)
+	}
+	return field, fieldStruct, nil
+}
+
+// setExpressionDefaults applies default expressions to blank fields of value, and returns
+// the fields expressions resolved blank for
+func (configor *Configor) setExpressionDefaults(root, value reflect.Value) ([]reflect.Value, error) {
+	value = reflect.Indirect(value)
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var unresolved []reflect.Value
+	for i := 0; i < value.NumField(); i++ {
+		var (
+			fieldStruct = value.Type().Field(i)
+			field       = value.Field(i)
+		)
+		if !field.CanSet() {
+			continue
+		}
+
+		if defaultValue := fieldStruct.Tag.Get("default"); isDefaultExpression(defaultValue) {
+			if field.IsZero() {
+				resolved, err := configor.resolveDefaultExpression(root, defaultValue, 0)
+				if err != nil {
+					return nil, err
+				}
+				if resolved == "" {
+					unresolved = append(unresolved, field)
+				} else if err := configor.setValue(field, resolved); err != nil {
+					return nil, fmt.Errorf("invalid default value %v of %v: %v", resolved, fieldStruct.Name, err)
+				}
+			}
+			continue
+		}
+
+		var (
+			nested []reflect.Value
+			err    error
+		)
+		switch field.Kind() {
+		case reflect.Ptr, reflect.Struct:
+			if field.Kind() == reflect.Ptr && field.IsNil() {
+				continue
+			}
+			nested, err = configor.setExpressionDefaults(root, field)
+		case reflect.Slice:
+			for j := 0; j < field.Len() && err == nil; j++ {
+				var elemUnresolved []reflect.Value
+				elemUnresolved, err = configor.setExpressionDefaults(root, field.Index(j))
+				nested = append(nested, elemUnresolved...)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		unresolved = append(unresolved, nested...)
+	}
+	return unresolved, nil
+}
+
+// setTextDefaults applies the `default` tags creasty/defaults can't parse to blank fields: the ones decode hooks
+// handle, the ones of fields implementing encoding.TextUnmarshaler, such as ByteSize or Duration,
+// and escaped expressions, which creasty/defaults would keep the backslash of
+func (configor *Configor) setTextDefaults(value reflect.Value) error {
+	value = reflect.Indirect(value)
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		var (
+			fieldStruct = value.Type().Field(i)
+			field       = value.Field(i)
+		)
+		if !field.CanSet() {
+			continue
+		}
+
+		if defaultValue := fieldStruct.Tag.Get("default"); defaultValue != "" && defaultValue != "-" && !isDefaultExpression(defaultValue) && field.IsZero() {
+			handled, err := configor.setTextDefault(field, defaultValue)
+			if err != nil {
+				return fmt.Errorf("invalid default value %v of %v: %v", defaultValue, fieldStruct.Name, err)
+			}
+			if handled {
+				continue
+			}
+		}
+
+		switch field.Kind() {
+		case reflect.Ptr, reflect.Struct:
+			if field.Kind() == reflect.Ptr && field.IsNil() {
+				continue
+			}
+			if err := configor.setTextDefaults(field); err != nil {
+				return err
+			}
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				if err := configor.setTextDefaults(field.Index(j)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setTextDefault sets a literal `default` tag to a blank field, unless it is left to creasty/defaults
+func (configor *Configor) setTextDefault(field reflect.Value, defaultValue string) (bool, error) {
+	literal := literalDefault(defaultValue)
+	if result, err := configor.runDecodeHooks(literal, field.Type()); err != nil || result != nil {
+		if err != nil {
+			return true, err
+		}
+		return true, setHookResult(field, result)
+	}
+
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return true, unmarshaler.UnmarshalText([]byte(literal))
+	}
+
+	if literal != defaultValue {
+		return true, configor.setValue(field, literal)
+	}
+	return false, nil
+}
+
+// setMapDefaults seeds map fields with the entries of their `default` tag, a json object,
+// for keys not loaded from any source, and applies defaults to struct map values.
+// creasty/defaults only initializes nil maps, and never visits map values
+func (configor *Configor) setMapDefaults(root interface{}, value reflect.Value, keys []string) error {
+	value = reflect.Indirect(value)
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		var (
+			fieldStruct = value.Type().Field(i)
+			field       = value.Field(i)
+			fieldKeys   = getKeysForField(keys, &fieldStruct)
+		)
+		if !field.CanSet() {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Ptr:
+			if !field.IsNil() {
+				if err := configor.setMapDefaults(root, field, fieldKeys); err != nil {
+					return err
+				}
+			}
+		case reflect.Struct:
+			if err := configor.setMapDefaults(root, field, fieldKeys); err != nil {
+				return err
+			}
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				if err := configor.setMapDefaults(root, field.Index(j), append(fieldKeys, fmt.Sprint(j))); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			if defaultValue := fieldStruct.Tag.Get("default"); defaultValue != "" && defaultValue != "-" && !isDefaultExpression(defaultValue) {
+				if err := seedMap(field, literalDefault(defaultValue)); err != nil {
+					return fmt.Errorf("invalid default value of %v: %v", fieldStruct.Name, err)
+				}
+			}
+			if err := configor.setMapValueDefaults(root, field, fieldKeys); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seedMap adds the entries of defaultValue missing in field
+func seedMap(field reflect.Value, defaultValue string) error {
+	seed := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(defaultValue), seed.Interface()); err != nil {
+		return err
+	}
+
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	iter := seed.Elem().MapRange()
+	for iter.Next() {
+		if !field.MapIndex(iter.Key()).IsValid() {
+			field.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	return nil
+}
+
+// setMapValueDefaults applies defaults to the struct values of a map, which are not addressable,
+// so each value is copied, updated and stored back
+func (configor *Configor) setMapValueDefaults(root interface{}, field reflect.Value, keys []string) error {
+	if indirectType(field.Type().Elem()).Kind() != reflect.Struct {
+		return nil
+	}
+
+	iter := field.MapRange()
+	for iter.Next() {
+		elem := reflect.New(field.Type().Elem())
+		elem.Elem().Set(iter.Value())
+
+		config := elem.Interface()
+		if elem.Elem().Kind() == reflect.Ptr {
+			if elem.Elem().IsNil() {
+				continue
+			}
+			config = elem.Elem().Interface()
+		}
+		if err := configor.setDefaults(root, config, append(keys[:len(keys):len(keys)], fmt.Sprint(iter.Key()))); err != nil {
+			return err
+		}
+		field.SetMapIndex(iter.Key(), elem.Elem())
+	}
+	return nil
+}
+
+// tagDefaultValues returns the printed values creasty/defaults gives the leaves of value with a literal
+// `default` tag by dotted key path, so the changes of SetDefaults methods can be told apart from them
+func tagDefaultValues(value reflect.Value, keys []string) map[string]string {
+	values := map[string]string{}
+	var walk func(value reflect.Value, keys []string)
+	walk = func(value reflect.Value, keys []string) {
+		value = reflect.Indirect(value)
+		if value.Kind() != reflect.Struct {
+			return
+		}
+
+		for i := 0; i < value.NumField(); i++ {
+			var (
+				fieldStruct = value.Type().Field(i)
+				field       = value.Field(i)
+				fieldKeys   = getKeysForField(keys, &fieldStruct)
+			)
+			if !fieldStruct.IsExported() {
+				continue
+			}
+
+			if defaultValue := fieldStruct.Tag.Get("default"); defaultValue != "" && defaultValue != "-" && !isDefaultExpression(defaultValue) {
+				// the default of a blank field of the same type, in a struct of its own
+				holder := reflect.New(reflect.StructOf([]reflect.StructField{{
+					Name: "Value",
+					Type: field.Type(),
+					Tag:  reflect.StructTag(`default:` + strconv.Quote(defaultValue)),
+				}}))
+				if err := defaults.Set(holder.Interface()); err == nil {
+					for key, value := range flattenValue(holder.Elem().Field(0), fieldKeys) {
+						values[key] = value
+					}
+				}
+			}
+
+			switch field.Kind() {
+			case reflect.Ptr, reflect.Struct:
+				walk(field, fieldKeys)
+			case reflect.Slice:
+				for j := 0; j < field.Len(); j++ {
+					walk(field.Index(j), append(fieldKeys, fmt.Sprint(j)))
+				}
+			}
+		}
+	}
+	walk(value, keys)
+	return values
+}
+
+// flattenValue returns the printed values of all the leaves of value by dotted key path
+func flattenValue(value reflect.Value, keys []string) map[string]string {
+	values := map[string]string{}
+	var flatten func(value reflect.Value, keys []string)
+	flatten = func(value reflect.Value, keys []string) {
+		switch value.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if !value.IsNil() {
+				flatten(value.Elem(), keys)
+				return
+			}
+		case reflect.Struct:
+			// values printed as a whole, such as Rate or Optional, are leaves
+			_, isStringer := value.Interface().(fmt.Stringer)
+			_, isMarshaler := value.Interface().(json.Marshaler)
+			if !isStringer && !isMarshaler && value.NumField() > 0 {
+				for i := 0; i < value.NumField(); i++ {
+					if fieldStruct := value.Type().Field(i); fieldStruct.IsExported() {
+						flatten(value.Field(i), getKeysForField(keys, &fieldStruct))
+					}
+				}
+				return
+			}
+		case reflect.Slice, reflect.Array:
+			if value.Len() > 0 {
+				for i := 0; i < value.Len(); i++ {
+					flatten(value.Index(i), append(keys[:len(keys):len(keys)], fmt.Sprint(i)))
+				}
+				return
+			}
+		case reflect.Map:
+			if value.Len() > 0 {
+				iter := value.MapRange()
+				for iter.Next() {
+					flatten(iter.Value(), append(keys[:len(keys):len(keys)], fmt.Sprint(iter.Key())))
+				}
+				return
+			}
+		}
+		if len(keys) > 0 {
+			values[strings.Join(keys, ".")] = fmt.Sprintf("%v", value)
+		}
+	}
+	flatten(value, keys)
+	return values
+}
@@ -0,0 +1,279 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+)
+
+func TestMapAndSliceDefaults(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int  `default:"8080"`
+		TLS  bool `default:"true"`
+	}
+	type config struct {
+		Servers  map[string]Server
+		Backends map[string]*Server
+		Replicas []Server
+		Labels   map[string]string `default:"{\"team\": \"platform\", \"tier\": \"backend\"}"`
+		Pools    map[string]Server `default:"{\"default\": {\"Host\": \"localhost\"}}"`
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`
+servers:
+  eu:
+    host: eu.example.org
+  us:
+    host: us.example.org
+    port: 443
+backends:
+  db:
+    host: db.example.org
+replicas:
+- host: replica0.example.org
+labels:
+  team: core
+`)
+	file.Close()
+
+	os.Setenv("DEFAULTS_SERVERS_EU_PORT", "8443")
+	defer os.Setenv("DEFAULTS_SERVERS_EU_PORT", "")
+
+	var result config
+	if err := New(&Config{ENVPrefix: "DEFAULTS"}).Load(&result, file.Name()); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		Servers: map[string]Server{
+			"eu": {Host: "eu.example.org", Port: 8443, TLS: true},
+			"us": {Host: "us.example.org", Port: 443, TLS: true},
+		},
+		Backends: map[string]*Server{"db": {Host: "db.example.org", Port: 8080, TLS: true}},
+		Replicas: []Server{{Host: "replica0.example.org", Port: 8080, TLS: true}},
+		Labels:   map[string]string{"team": "core", "tier": "backend"},
+		Pools:    map[string]Server{"default": {Host: "localhost", Port: 8080, TLS: true}},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestDefaultExpressions(t *testing.T) {
+	type config struct {
+		Hostname  string   `default:"${DEFAULTS_HOSTNAME}"`
+		Missing   string   `default:"${DEFAULTS_MISSING}"`
+		Address   string   `default:"${DEFAULTS_HOSTNAME}:8080"`
+		CacheHost string   `default:"@DB.Host"`
+		AuthHost  string   `default:"@DB.Replica"`
+		Bucket    string   `default:"{{ .Env }}-bucket"`
+		Region    string   `default:"{{ env \"DEFAULTS_REGION\" }}"`
+		Timeout   Duration `default:"@DB.Timeout"`
+		DB        struct {
+			Host    string
+			Replica string   `default:"replica.example.org"`
+			Timeout Duration `default:"30s"`
+		}
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`
+db:
+  host: db.example.org
+`)
+	file.Close()
+
+	var result config
+	err = New(&Config{
+		Environment: "production",
+		Env:         map[string]string{"DEFAULTS_HOSTNAME": "app0", "DEFAULTS_REGION": "eu-west"},
+	}).Load(&result, file.Name())
+	if err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		Hostname:  "app0",
+		Address:   "app0:8080",
+		CacheHost: "db.example.org",
+		AuthHost:  "replica.example.org",
+		Bucket:    "production-bucket",
+		Region:    "eu-west",
+		Timeout:   Duration(30 * time.Second),
+	}
+	expected.DB.Host = "db.example.org"
+	expected.DB.Replica = "replica.example.org"
+	expected.DB.Timeout = Duration(30 * time.Second)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+
+	var escaped struct {
+		Home     string `default:"\\@home"`
+		Template string `default:"\\{{ .Env }} and ${HOME}"`
+	}
+	if err := New(&Config{Env: map[string]string{}}).Load(&escaped); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+	if escaped.Home != "@home" || escaped.Template != "{{ .Env }} and ${HOME}" {
+		t.Errorf("Escaped default expressions should be literal, instead got %+v", escaped)
+	}
+
+	var invalid struct {
+		Host string `default:"@DB.Unknown"`
+	}
+	if err := New(&Config{Env: map[string]string{}}).Load(&invalid); err == nil {
+		t.Errorf("Should get error when a default expression refers to an unknown field")
+	}
+}
+
+type defaulterPool struct {
+	Workers int `default:"1"`
+	Queue   int `default:"10"`
+}
+
+func (pool *defaulterPool) SetDefaults() {
+	pool.Workers = pool.Queue * 2
+}
+
+type defaulterConfig struct {
+	Name   string `default:"app"`
+	Pool   defaulterPool
+	Pools  map[string]defaulterPool
+	Shards []defaulterPool
+	calls  int
+}
+
+func (config *defaulterConfig) SetDefaults() {
+	config.calls++
+	if config.Pool.Workers > 10 {
+		config.Name = "large-app"
+	}
+}
+
+func TestDefaulter(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`
+pools:
+  batch:
+    queue: 3
+shards:
+- queue: 4
+`)
+	file.Close()
+
+	var result defaulterConfig
+	configor := New(&Config{Env: map[string]string{}})
+	if err := configor.Load(&result, file.Name()); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := defaulterConfig{
+		Name:   "large-app",
+		Pool:   defaulterPool{Workers: 20, Queue: 10},
+		Pools:  map[string]defaulterPool{"batch": {Workers: 6, Queue: 3}},
+		Shards: []defaulterPool{{Workers: 8, Queue: 4}},
+		calls:  1,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+
+	origins := map[string]string{}
+	for _, origin := range configor.Explain() {
+		origins[origin.Key] = origin.Source
+	}
+	for key, source := range map[string]string{
+		"name":                "default (method)",
+		"pool.workers":        "default (method)",
+		"pools.batch.workers": "default (method)",
+		"pools.batch.queue":   file.Name(),
+		"shards.0.workers":    "default (method)",
+	} {
+		if origins[key] != source {
+			t.Errorf("\nExpected origin of %v: %+v, \nGot: %+v", key, source, origins[key])
+		}
+	}
+	if _, ok := origins["pool.queue"]; ok {
+		t.Errorf("Tag defaults should not be recorded as set by the SetDefaults method")
+	}
+}
+
+type parityNested struct {
+	Host  string `default:"localhost"`
+	Port  int    `default:"8080"`
+	Ratio float64
+}
+
+type parityConfig struct {
+	Bool     bool           `default:"true"`
+	Int      int            `default:"0x10"`
+	Int8     int8           `default:"-8"`
+	Int32    int32          `default:"32"`
+	Int64    int64          `default:"64"`
+	Uint     uint           `default:"1"`
+	Uint16   uint16         `default:"16"`
+	Float32  float32        `default:"1.5"`
+	Float64  float64        `default:"2.5"`
+	String   string         `default:"configor"`
+	Set      string         `default:"unused"`
+	Skipped  string         `default:"-"`
+	Timeout  time.Duration  `default:"1m30s"`
+	Ints     []int          `default:"[1, 2, 3]"`
+	Empty    []string       `default:"[]"`
+	Labels   map[string]int `default:"{\"a\": 1}"`
+	Ptr      *int           `default:"5"`
+	Nested   parityNested
+	Decoded  parityNested  `default:"{\"Host\": \"example.org\", \"Ratio\": 0.5}"`
+	NestedP  *parityNested `default:"{}"`
+	Items    []parityNested
+	Untagged string
+}
+
+func TestDefaultsParity(t *testing.T) {
+	// tag defaults give the same results as github.com/creasty/defaults
+	items := []parityNested{{Host: "db.example.org"}, {Port: 5432}}
+	var expected, result parityConfig
+	expected.Set, result.Set = "set", "set"
+	expected.Items, result.Items = append([]parityNested{}, items...), append([]parityNested{}, items...)
+
+	if err := defaults.Set(&expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(&Config{}).setDefaults(&result, &result, nil); err != nil {
+		t.Fatalf("No error should happen when setting defaults, but got %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+
+	// like creasty/defaults, int64 fields take durations, and invalid defaults are ignored
+	var lenient struct {
+		Timeout int64 `default:"1s"`
+		Port    int   `default:"http"`
+	}
+	if err := New(&Config{}).setDefaults(&lenient, &lenient, nil); err != nil {
+		t.Fatalf("No error should happen when setting defaults, but got %v", err)
+	}
+	if lenient.Timeout != int64(time.Second) || lenient.Port != 0 {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", "{Timeout:1000000000 Port:0}", lenient)
+	}
+}
@@ -0,0 +1,254 @@
+package configor
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// getenv returns the value of env var name, from the Env snapshot if any, or from the process env
+func (config *Config) getenv(name string) string {
+	if config.Env != nil {
+		return config.Env[name]
+	}
+	return os.Getenv(name)
+}
+
+// environ returns env vars in the `NAME=value` form, from the Env snapshot if any, or from the process env
+func (config *Config) environ() []string {
+	if config.Env == nil {
+		return os.Environ()
+	}
+	result := make([]string, 0, len(config.Env))
+	for name, value := range config.Env {
+		result = append(result, name+"="+value)
+	}
+	return result
+}
+
+// lookupENVWithPrefix returns the non blank env vars whose name starts with prefix, keyed by the rest of their name
+func (config *Config) lookupENVWithPrefix(prefix string) map[string]string {
+	result := map[string]string{}
+	for _, env := range config.environ() {
+		if i := strings.Index(env, "="); i > len(prefix) && strings.HasPrefix(env, prefix) && env[i+1:] != "" {
+			result[env[len(prefix):i]] = env[i+1:]
+		}
+	}
+	return result
+}
+
+// processMap loads the entries of a `map[string]T` field from env vars named after
+// the field's envNames, e.g. CONFIGOR_LABELS_TEAM for key `team` of field `Labels`.
+// Entries of existing keys are looked up by name, new keys are discovered by
+// scanning the process env for the field's env var names. New struct entries get their `default` tags
+// and SetDefaults before their env vars are loaded
+func (configor *Configor) processMap(root interface{}, field reflect.Value, envNames []string, keys []string, prefixes []string) error {
+	var (
+		separator = configor.getENVSeparator()
+		elemType  = field.Type().Elem()
+		isStruct  = indirectType(elemType).Kind() == reflect.Struct
+		// map keys by the env var name segment they are loaded from
+		mapKeys = map[string]string{}
+	)
+
+	iter := field.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		for _, name := range configor.getENVNames([]string{key}) {
+			mapKeys[name] = key
+		}
+	}
+
+	// discover new keys
+	for _, envName := range envNames {
+		for name := range configor.lookupENVWithPrefix(envName + separator) {
+			if isStruct {
+				found := false
+				for segment := range mapKeys {
+					found = found || strings.HasPrefix(name, segment+separator)
+				}
+				if found {
+					continue
+				}
+				name = configor.mapKeySegment(name, indirectType(elemType))
+			}
+			if _, ok := mapKeys[name]; !ok {
+				mapKeys[name] = strings.ToLower(name)
+			}
+		}
+	}
+
+	segments := make([]string, 0, len(mapKeys))
+	for segment := range mapKeys {
+		segments = append(segments, segment)
+	}
+	sort.Strings(segments)
+
+	for _, segment := range segments {
+		var (
+			key   = mapKeys[segment]
+			value = reflect.New(elemType).Elem()
+		)
+		existing := field.MapIndex(reflect.ValueOf(key).Convert(field.Type().Key()))
+		if existing.IsValid() {
+			value.Set(existing)
+		}
+
+		if isStruct {
+			elem := value
+			if elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					elem.Set(reflect.New(elemType.Elem()))
+				}
+				elem = elem.Elem()
+			}
+			if !existing.IsValid() {
+				// entries loaded from files got their defaults with the rest of the config
+				if err := configor.setDefaults(root, elem.Addr().Interface(), append(keys[:len(keys):len(keys)], key)); err != nil {
+					return err
+				}
+			}
+			before := reflect.New(elem.Type()).Elem()
+			before.Set(elem)
+			if err := configor.processTags(root, elem.Addr().Interface(), append(keys[:len(keys):len(keys)], key), append(prefixes[:len(prefixes):len(prefixes)], key)...); err != nil {
+				return err
+			}
+			if !existing.IsValid() && reflect.DeepEqual(elem.Interface(), before.Interface()) {
+				// nothing loaded for a discovered key
+				continue
+			}
+		} else {
+			loaded := false
+			for _, envName := range envNames {
+				env := envName + separator + segment
+				if envValue := configor.getenv(env); envValue != "" {
+					if configor.Config.Debug || configor.Config.Verbose {
+						fmt.Printf("Loading configuration for map key `%v` from env %v...\n", key, env)
+					}
+					if err := configor.setValue(value, envValue); err != nil {
+						return err
+					}
+					configor.recordOrigin(strings.Join(append(keys[:len(keys):len(keys)], key), "."), "env "+env, "")
+					loaded = true
+					break
+				}
+			}
+			if !loaded {
+				continue
+			}
+		}
+
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		field.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), value)
+	}
+	return nil
+}
+
+// mapKeySegment returns the segment of name, the rest of an env var name of a map of structs of type typ,
+// naming the map key: the shortest one followed by the env var name of a field of typ,
+// e.g. `EU_WEST` of `EU_WEST_HOST`, or else the first one
+func (configor *Configor) mapKeySegment(name string, typ reflect.Type) string {
+	separator := configor.getENVSeparator()
+	for i := strings.Index(name, separator); i > 0; {
+		if configor.isFieldENV(name[i+len(separator):], typ) {
+			return name[:i]
+		}
+		next := strings.Index(name[i+len(separator):], separator)
+		if next < 0 {
+			break
+		}
+		i += len(separator) + next
+	}
+	return strings.Split(name, separator)[0]
+}
+
+// isFieldENV reports whether name is the env var name of a field of struct typ relative to
+// the struct, or starts with the one of a nested struct, e.g. `HOST` or `TLS_CERT`
+func (configor *Configor) isFieldENV(name string, typ reflect.Type) bool {
+	separator := configor.getENVSeparator()
+	for i := 0; i < typ.NumField(); i++ {
+		fieldStruct := typ.Field(i)
+		if !fieldStruct.IsExported() || fieldStruct.Tag.Get("env") != "" {
+			continue
+		}
+		if fieldStruct.Anonymous && fieldStruct.Tag.Get("anonymous") == "true" {
+			if fieldType := indirectType(fieldStruct.Type); fieldType.Kind() == reflect.Struct && configor.isFieldENV(name, fieldType) {
+				return true
+			}
+			continue
+		}
+		for _, envName := range configor.getENVNames([]string{fieldStruct.Name}) {
+			if name == envName || strings.HasPrefix(name, envName+separator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// processSlice loads the elements of a slice of primitives from indexed env vars named after
+// the field's envNames, e.g. CONFIGOR_HOSTS_0, CONFIGOR_HOSTS_1. Elements loaded from configuration
+// files are overwritten, and new elements are appended until an index is missing
+func (configor *Configor) processSlice(field reflect.Value, envNames []string, keys []string) error {
+	separator := configor.getENVSeparator()
+	for i := 0; ; i++ {
+		var (
+			env      string
+			envValue string
+		)
+		for _, envName := range envNames {
+			if env = envName + separator + fmt.Sprint(i); configor.getenv(env) != "" {
+				envValue = configor.getenv(env)
+				break
+			}
+		}
+
+		if envValue == "" {
+			if i < field.Len() {
+				continue
+			}
+			return nil
+		}
+
+		if configor.Config.Debug || configor.Config.Verbose {
+			fmt.Printf("Loading configuration for slice index `%v` from env %v...\n", i, env)
+		}
+
+		if i < field.Len() {
+			if err := configor.setValue(field.Index(i), envValue); err != nil {
+				return err
+			}
+		} else {
+			value := reflect.New(field.Type().Elem()).Elem()
+			if err := configor.setValue(value, envValue); err != nil {
+				return err
+			}
+			field.Set(reflect.Append(field, value))
+		}
+		configor.recordOrigin(strings.Join(append(keys[:len(keys):len(keys)], fmt.Sprint(i)), "."), "env "+env, "")
+	}
+}
+
+// setSliceValue sets a slice field from a value delimited by sep, e.g. `a,b,c`
+func (configor *Configor) setSliceValue(field reflect.Value, value string, sep string) error {
+	values := strings.Split(value, sep)
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, v := range values {
+		if err := configor.setValue(slice.Index(i), strings.TrimSpace(v)); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
@@ -0,0 +1,127 @@
+package configor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMapFromEnv(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int `default:"8080"`
+	}
+	type config struct {
+		Labels  map[string]string
+		Limits  map[string]int
+		Servers map[string]*Server
+		Mirrors map[string]Server
+	}
+
+	os.Setenv("MAPS_LABELS_TEAM", "core")
+	os.Setenv("MAPS_LIMITS_CPU", "4")
+	os.Setenv("MAPS_SERVERS_EU_WEST_PORT", "8443")
+	os.Setenv("MAPS_SERVERS_US_HOST", "us.example.org")
+	defer os.Setenv("MAPS_LABELS_TEAM", "")
+	defer os.Setenv("MAPS_LIMITS_CPU", "")
+	defer os.Setenv("MAPS_SERVERS_EU_WEST_PORT", "")
+	defer os.Setenv("MAPS_SERVERS_US_HOST", "")
+	os.Setenv("MAPS_MIRRORS_ASIA_HOST", "asia.example.org")
+	defer os.Setenv("MAPS_MIRRORS_ASIA_HOST", "")
+	os.Setenv("MAPS_MIRRORS_EU_WEST_HOST", "eu.example.org")
+	defer os.Setenv("MAPS_MIRRORS_EU_WEST_HOST", "")
+
+	result := config{
+		Labels:  map[string]string{"team": "platform", "app": "configor"},
+		Servers: map[string]*Server{"eu-west": {Host: "eu.example.org", Port: 443}},
+	}
+	if err := New(&Config{ENVPrefix: "MAPS", ErrorOnUnknownENVs: true}).Load(&result); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		Labels: map[string]string{"team": "core", "app": "configor"},
+		Limits: map[string]int{"cpu": 4},
+		Servers: map[string]*Server{
+			"eu-west": {Host: "eu.example.org", Port: 8443},
+			"us":      {Host: "us.example.org", Port: 8080},
+		},
+		Mirrors: map[string]Server{"asia": {Host: "asia.example.org", Port: 8080}, "eu_west": {Host: "eu.example.org", Port: 8080}},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestPrimitiveSliceFromEnv(t *testing.T) {
+	type config struct {
+		Hosts []string
+		Ports []int `sep:","`
+		Tags  []string
+	}
+
+	os.Setenv("SLICES_HOSTS_1", "http://override.org")
+	os.Setenv("SLICES_HOSTS_2", "http://appended.org")
+	os.Setenv("SLICES_PORTS", "80, 443")
+	os.Setenv("SLICES_TAGS_0", "a")
+	os.Setenv("SLICES_TAGS_1", "b")
+	defer os.Setenv("SLICES_HOSTS_1", "")
+	defer os.Setenv("SLICES_HOSTS_2", "")
+	defer os.Setenv("SLICES_PORTS", "")
+	defer os.Setenv("SLICES_TAGS_0", "")
+	defer os.Setenv("SLICES_TAGS_1", "")
+
+	result := config{Hosts: []string{"http://example.org", "http://jinzhu.me"}}
+	if err := New(&Config{ENVPrefix: "SLICES"}).Load(&result); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		Hosts: []string{"http://example.org", "http://override.org", "http://appended.org"},
+		Ports: []int{80, 443},
+		Tags:  []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestENVSnapshot(t *testing.T) {
+	type config struct {
+		APPName string
+		DB      struct {
+			Name string
+			Port uint
+		}
+		Labels map[string]string
+	}
+
+	var tests = []struct {
+		name     string
+		env      map[string]string
+		expected config
+	}{
+		{"development", map[string]string{"APP_APP_NAME": "dev", "APP_DB_PORT": "5432", "CONFIGOR_ENV_PREFIX": "APP"}, config{APPName: "dev"}},
+		{"production", map[string]string{"CONFIGOR_APP_NAME": "prod", "CONFIGOR_LABELS_TEAM": "core", "CONFIGOR_ENV": "production"}, config{APPName: "prod", Labels: map[string]string{"team": "core"}}},
+	}
+	tests[0].expected.DB.Port = 5432
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var result config
+			configor := New(&Config{Env: test.env})
+			if err := configor.Load(&result); err != nil {
+				t.Fatalf("No error should happen when load configurations, but got %v", err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("\nExpected: %+v, \nGot: %+v", test.expected, result)
+			}
+			if env := configor.GetEnvironment(); test.env["CONFIGOR_ENV"] != "" && env != test.env["CONFIGOR_ENV"] {
+				t.Errorf("Environment should be %v, instead got %v", test.env["CONFIGOR_ENV"], env)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package configor
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ENVVar describes an env var a config field is loaded from
+type ENVVar struct {
+	// Env var name, in priority order when several name mappers are configured.
+	// `{N}` stands for a slice index, and `{KEY}` for a map key
+	Names []string `json:"names"`
+	// Dotted key path of the field in configuration files
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required"`
+	Validate    string `json:"validate,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// ENVVars returns the env vars config is loaded from, with the same rules as Load: the ENV prefix,
+// `env` and `anonymous` tags, slice indexes and map keys. Descriptions are read from `desc` tags
+func (configor *Configor) ENVVars(config interface{}) []ENVVar {
+	var prefixes []string
+	if prefix := configor.getENVPrefix(config); prefix != "-" {
+		prefixes = []string{prefix}
+	}
+	return configor.envVars(indirectType(reflect.TypeOf(config)), nil, prefixes)
+}
+
+func (configor *Configor) envVars(configType reflect.Type, keys []string, prefixes []string) []ENVVar {
+	if configType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var result []ENVVar
+	for i := 0; i < configType.NumField(); i++ {
+		fieldStruct := configType.Field(i)
+		if !fieldStruct.IsExported() {
+			continue
+		}
+
+		var (
+			envNames  []string
+			fieldKeys = getKeysForField(keys, &fieldStruct)
+			fieldType = indirectType(fieldStruct.Type)
+		)
+		if envName := fieldStruct.Tag.Get("env"); envName != "" {
+			envNames = []string{envName}
+		} else {
+			envNames = configor.getENVNames(append(prefixes[:len(prefixes):len(prefixes)], fieldStruct.Name))
+		}
+
+		if fieldType.Kind() == reflect.Struct && !isLeafType(fieldType) {
+			result = append(result, configor.envVars(fieldType, fieldKeys, getPrefixForStruct(prefixes, &fieldStruct))...)
+			continue
+		}
+
+		envVar := ENVVar{
+			Names:       envNames,
+			Key:         strings.Join(fieldKeys, "."),
+			Type:        fieldStruct.Type.String(),
+			Default:     literalDefault(fieldStruct.Tag.Get("default")),
+			Required:    fieldStruct.Tag.Get("required") == "true",
+			Validate:    fieldStruct.Tag.Get("validate"),
+			Description: fieldStruct.Tag.Get("desc"),
+		}
+		if envVar.Default == "-" {
+			envVar.Default = ""
+		}
+		result = append(result, envVar)
+
+		if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Map {
+			continue
+		}
+
+		var (
+			separator = configor.getENVSeparator()
+			elemType  = indirectType(fieldType.Elem())
+		)
+		switch {
+		case fieldType.Kind() == reflect.Slice && elemType.Kind() == reflect.Struct && !isLeafType(elemType):
+			result = append(result, configor.envVars(elemType, append(fieldKeys, "{N}"), append(getPrefixForStruct(prefixes, &fieldStruct), "{N}"))...)
+		case fieldType.Kind() == reflect.Slice:
+			result = append(result, ENVVar{Names: appendENVNames(envNames, separator, "{N}"), Key: envVar.Key + ".{N}", Type: fieldType.Elem().String()})
+		case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String && elemType.Kind() == reflect.Struct && !isLeafType(elemType):
+			result = append(result, configor.envVars(elemType, append(fieldKeys, "{KEY}"), append(getPrefixForStruct(prefixes, &fieldStruct), "{KEY}"))...)
+		case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String:
+			result = append(result, ENVVar{Names: appendENVNames(envNames, separator, "{KEY}"), Key: envVar.Key + ".{KEY}", Type: fieldType.Elem().String()})
+		}
+	}
+	return result
+}
+
+// appendENVNames appends suffix to each env var name
+func appendENVNames(envNames []string, separator, suffix string) []string {
+	result := make([]string, 0, len(envNames))
+	for _, name := range envNames {
+		result = append(result, name+separator+suffix)
+	}
+	return result
+}
+
+// isLeafType reports whether values of a struct type are decoded as a whole, such as Rate, Optional or time.Time
+func isLeafType(typ reflect.Type) bool {
+	ptr := reflect.PtrTo(typ)
+	return ptr.Implements(textUnmarshalerType) || ptr.Implements(jsonUnmarshalerType)
+}
+
+// WriteENVReference writes the env vars config is loaded from as a `markdown` table, `csv` or `json`,
+// e.g. from a program run by `go generate` to document the env vars a service accepts
+func (configor *Configor) WriteENVReference(w io.Writer, config interface{}, format string) error {
+	envVars := configor.ENVVars(config)
+
+	switch format {
+	case "json":
+		if envVars == nil {
+			envVars = []ENVVar{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(envVars)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"Name", "Key", "Type", "Default", "Required", "Validate", "Description"})
+		for _, envVar := range envVars {
+			writer.Write([]string{strings.Join(envVar.Names, " "), envVar.Key, envVar.Type, envVar.Default, fmt.Sprint(envVar.Required), envVar.Validate, envVar.Description})
+		}
+		writer.Flush()
+		return writer.Error()
+	case "markdown", "md":
+		escape := strings.NewReplacer("|", `\|`, "\n", " ")
+		code := func(value string) string {
+			if value == "" {
+				return ""
+			}
+			return "`" + escape.Replace(value) + "`"
+		}
+
+		fmt.Fprintln(w, "| Name | Type | Default | Required | Validate | Description |")
+		fmt.Fprintln(w, "| ---- | ---- | ------- | -------- | -------- | ----------- |")
+		for _, envVar := range envVars {
+			names := make([]string, 0, len(envVar.Names))
+			for _, name := range envVar.Names {
+				names = append(names, code(name))
+			}
+			required := ""
+			if envVar.Required {
+				required = "yes"
+			}
+			_, err := fmt.Fprintf(w, "| %v | %v | %v | %v | %v | %v |\n", strings.Join(names, "<br>"), code(envVar.Type), code(envVar.Default), required, code(envVar.Validate), escape.Replace(envVar.Description))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown env reference format %v, should be markdown, csv or json", format)
+	}
+}
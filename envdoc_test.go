@@ -0,0 +1,76 @@
+package configor
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type envDocConfig struct {
+	APPName string `default:"app" desc:"Name of the app | service"`
+	DB      struct {
+		Host     string `required:"true" validate:"hostname" desc:"Database host"`
+		Password string `env:"DB_PASSWORD"`
+	}
+	Details struct {
+		Description string
+	} `anonymous:"true"`
+	Timeout  Duration `default:"30s"`
+	Hosts    []string
+	Labels   map[string]string
+	Contacts []struct {
+		Email string `validate:"email"`
+	}
+	Pools map[string]struct {
+		Size int
+	}
+	internal string
+}
+
+func TestENVVars(t *testing.T) {
+	envVars := New(&Config{ENVPrefix: "APP", Env: map[string]string{}}).ENVVars(&envDocConfig{})
+
+	expected := []ENVVar{
+		{Names: []string{"APP_APP_NAME"}, Key: "appname", Type: "string", Default: "app", Description: "Name of the app | service"},
+		{Names: []string{"APP_DB_HOST"}, Key: "db.host", Type: "string", Required: true, Validate: "hostname", Description: "Database host"},
+		{Names: []string{"DB_PASSWORD"}, Key: "db.password", Type: "string"},
+		{Names: []string{"APP_DETAILS_DESCRIPTION"}, Key: "details.description", Type: "string"},
+		{Names: []string{"APP_TIMEOUT"}, Key: "timeout", Type: "configor.Duration", Default: "30s"},
+		{Names: []string{"APP_HOSTS"}, Key: "hosts", Type: "[]string"},
+		{Names: []string{"APP_HOSTS_{N}"}, Key: "hosts.{N}", Type: "string"},
+		{Names: []string{"APP_LABELS"}, Key: "labels", Type: "map[string]string"},
+		{Names: []string{"APP_LABELS_{KEY}"}, Key: "labels.{KEY}", Type: "string"},
+		{Names: []string{"APP_CONTACTS"}, Key: "contacts", Type: "[]struct { Email string \"validate:\\\"email\\\"\" }"},
+		{Names: []string{"APP_CONTACTS_{N}_EMAIL"}, Key: "contacts.{N}.email", Type: "string", Validate: "email"},
+		{Names: []string{"APP_POOLS"}, Key: "pools", Type: "map[string]struct { Size int }"},
+		{Names: []string{"APP_POOLS_{KEY}_SIZE"}, Key: "pools.{KEY}.size", Type: "int"},
+	}
+	if !reflect.DeepEqual(envVars, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, envVars)
+	}
+}
+
+func TestWriteENVReference(t *testing.T) {
+	configor := New(&Config{ENVPrefix: "APP", Env: map[string]string{}})
+
+	var markdown bytes.Buffer
+	if err := configor.WriteENVReference(&markdown, &envDocConfig{}, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	if row := "| `APP_APP_NAME` | `string` | `app` |  |  | Name of the app \\| service |"; !strings.Contains(markdown.String(), row) {
+		t.Errorf("\nExpected markdown to contain: %+v, \nGot: %+v", row, markdown.String())
+	}
+
+	var csv bytes.Buffer
+	if err := configor.WriteENVReference(&csv, &envDocConfig{}, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	if row := "APP_DB_HOST,db.host,string,,true,hostname,Database host"; !strings.Contains(csv.String(), row) {
+		t.Errorf("\nExpected csv to contain: %+v, \nGot: %+v", row, csv.String())
+	}
+
+	if err := configor.WriteENVReference(&csv, &envDocConfig{}, "xml"); err == nil {
+		t.Errorf("Should get error for unknown formats")
+	}
+}
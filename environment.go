@@ -0,0 +1,104 @@
+package configor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// kubernetesNamespaceFile is mounted in every pod with a service account
+const kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// EnvironmentDetector detects the environment the application runs in
+type EnvironmentDetector interface {
+	// DetectEnvironment returns the detected environment, or a blank string if it can't tell
+	DetectEnvironment() string
+}
+
+// EnvironmentDetectorFunc is an adapter to use ordinary functions as EnvironmentDetector
+type EnvironmentDetectorFunc func() string
+
+// DetectEnvironment calls f()
+func (f EnvironmentDetectorFunc) DetectEnvironment() string {
+	return f()
+}
+
+type envVarDetector []string
+
+// EnvVarDetector detects the environment from the first non blank env var of names, e.g. `APP_ENV`, `GO_ENV`
+func EnvVarDetector(names ...string) EnvironmentDetector {
+	return envVarDetector(names)
+}
+
+// DetectEnvironment detects the environment from the process env
+func (names envVarDetector) DetectEnvironment() string {
+	return names.detect(os.Getenv)
+}
+
+func (names envVarDetector) detect(getenv func(string) string) string {
+	for _, name := range names {
+		if env := getenv(name); env != "" {
+			return env
+		}
+	}
+	return ""
+}
+
+// detect runs detector, looking up env vars in the Env snapshot if any
+func (configor *Configor) detect(detector EnvironmentDetector) string {
+	if names, ok := detector.(envVarDetector); ok {
+		return names.detect(configor.getenv)
+	}
+	return detector.DetectEnvironment()
+}
+
+// HostnameDetector detects env when the hostname matches pattern, e.g. `^prod-`
+func HostnameDetector(pattern *regexp.Regexp, env string) EnvironmentDetector {
+	return EnvironmentDetectorFunc(func() string {
+		if hostname, err := os.Hostname(); err == nil && pattern.MatchString(hostname) {
+			return env
+		}
+		return ""
+	})
+}
+
+// FileDetector detects the environment from the content of a marker file, e.g. `/etc/environment-name`
+func FileDetector(file string) EnvironmentDetector {
+	return EnvironmentDetectorFunc(func() string {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	})
+}
+
+// KubernetesNamespaceDetector detects the environment from the namespace of the Kubernetes pod
+func KubernetesNamespaceDetector() EnvironmentDetector {
+	return FileDetector(kubernetesNamespaceFile)
+}
+
+// resolveEnvironmentAlias returns the canonical name of env
+func (configor *Configor) resolveEnvironmentAlias(env string) string {
+	if alias, ok := configor.EnvironmentAliases[env]; ok {
+		return alias
+	}
+	return env
+}
+
+// checkEnvironment returns an error if the environment is not one of the known Environments
+func (configor *Configor) checkEnvironment() error {
+	if len(configor.Environments) == 0 {
+		return nil
+	}
+
+	env := configor.GetEnvironment()
+	for _, known := range configor.Environments {
+		if env == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown environment %v, should be one of %v", env, strings.Join(configor.Environments, ", "))
+}
@@ -0,0 +1,52 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestEnvironmentDetectors(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	defer os.Remove(file.Name())
+	file.WriteString("staging\n")
+
+	os.Setenv("APP_ENV", "prod")
+	defer os.Setenv("APP_ENV", "")
+
+	aliases := map[string]string{"prod": "production"}
+	var tests = []struct {
+		detectors []EnvironmentDetector
+		expected  string
+	}{
+		{[]EnvironmentDetector{EnvVarDetector("GO_ENV", "APP_ENV"), FileDetector(file.Name())}, "production"},
+		{[]EnvironmentDetector{EnvVarDetector("GO_ENV"), FileDetector(file.Name())}, "staging"},
+		{[]EnvironmentDetector{HostnameDetector(regexp.MustCompile(".*"), "qa")}, "qa"},
+		{[]EnvironmentDetector{FileDetector(file.Name() + ".missing")}, "test"},
+	}
+	for _, test := range tests {
+		configor := New(&Config{EnvironmentDetectors: test.detectors, EnvironmentAliases: aliases})
+		if env := configor.GetEnvironment(); env != test.expected {
+			t.Errorf("Environment should be %v, instead got %v", test.expected, env)
+		}
+	}
+}
+
+func TestUnknownEnvironment(t *testing.T) {
+	var result struct{ Name string }
+
+	configor := New(&Config{Environment: "prod", Environments: []string{"development", "production"}})
+	if err := configor.Load(&result); err == nil {
+		t.Errorf("Should get error when loading configuration for unknown environment")
+	}
+
+	configor.EnvironmentAliases = map[string]string{"prod": "production"}
+	if err := configor.Load(&result); err != nil {
+		t.Errorf("Should NOT get error when loading configuration for aliased environment. Error: %v", err)
+	}
+}
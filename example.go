@@ -0,0 +1,407 @@
+package configor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateExample returns an example configuration file of config, in `yaml`, `json` or `toml` format, e.g. to check
+// in as `config.example.yml`. Values are the defaults of fields, from literal `default` tags and SetDefaults methods,
+// and in yaml and toml, keys are commented with their `desc` tags, required markers, `oneof` options and default expressions.
+// Slices and maps of structs get an example element
+func GenerateExample(config interface{}, format string) ([]byte, error) {
+	if format != "yaml" && format != "yml" && format != "json" && format != "toml" {
+		return nil, fmt.Errorf("unsupported example format %v, should be yaml, json or toml", format)
+	}
+
+	value := reflect.New(indirectType(reflect.TypeOf(config)))
+	// a blank env, so examples don't depend on where they are generated
+	loader := New(&Config{Environment: "development", Env: map[string]string{}})
+	if err := loader.setDefaults(value.Interface(), value.Interface(), nil); err != nil {
+		return nil, err
+	}
+
+	node, err := loader.exampleNode(value.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if format == "json" {
+		writeJSONNode(&buf, node, "")
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	}
+	if format == "toml" {
+		writeTOMLTable(&buf, node, nil)
+		return buf.Bytes(), nil
+	}
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	return buf.Bytes(), err
+}
+
+func (configor *Configor) exampleNode(value reflect.Value) (*yaml.Node, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.New(value.Type().Elem())
+		}
+		return configor.exampleNode(value.Elem())
+	}
+
+	if value.Kind() == reflect.Struct && !isLeafType(value.Type()) {
+		return configor.exampleStructNode(value)
+	}
+
+	switch value.Kind() {
+	case reflect.Slice:
+		if elemType := indirectType(value.Type().Elem()); value.Len() == 0 && elemType.Kind() == reflect.Struct && !isLeafType(elemType) {
+			elem, err := configor.exampleElemNode(elemType)
+			if err != nil {
+				return nil, err
+			}
+			return &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{elem}}, nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < value.Len(); i++ {
+			elem, err := configor.exampleNode(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, elem)
+		}
+		if value.Len() > 0 {
+			node.Style = 0
+		}
+		return node, nil
+	case reflect.Map:
+		if elemType := indirectType(value.Type().Elem()); value.Len() == 0 && elemType.Kind() == reflect.Struct && !isLeafType(elemType) {
+			elem, err := configor.exampleElemNode(elemType)
+			if err != nil {
+				return nil, err
+			}
+			return &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "example"}, elem}}, nil
+		}
+		node := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+		iter := value.MapRange()
+		var keys []string
+		values := map[string]reflect.Value{}
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			elem, err := configor.exampleNode(values[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, elem)
+		}
+		if len(keys) > 0 {
+			node.Style = 0
+		}
+		return node, nil
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(value.Interface()); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// exampleElemNode returns the example element of a slice or map of structs
+func (configor *Configor) exampleElemNode(elemType reflect.Type) (*yaml.Node, error) {
+	elem := reflect.New(elemType)
+	if err := configor.setDefaults(elem.Interface(), elem.Interface(), nil); err != nil {
+		return nil, err
+	}
+	return configor.exampleNode(elem.Elem())
+}
+
+func (configor *Configor) exampleStructNode(value reflect.Value) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < value.NumField(); i++ {
+		fieldStruct := value.Type().Field(i)
+		if !fieldStruct.IsExported() || fieldStruct.Tag.Get("yaml") == "-" || (fieldStruct.Tag.Get("yaml") == "" && fieldStruct.Tag.Get("json") == "-") {
+			continue
+		}
+
+		field := value.Field(i)
+		if defaultValue := fieldStruct.Tag.Get("default"); isDefaultExpression(defaultValue) {
+			// only known when loading
+			field = reflect.Zero(field.Type())
+		}
+
+		elem, err := configor.exampleNode(field)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") && elem.Kind == yaml.MappingNode {
+			node.Content = append(node.Content, elem.Content...)
+			continue
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: getFieldKey(&fieldStruct), HeadComment: exampleComment(&fieldStruct)}
+		node.Content = append(node.Content, key, elem)
+	}
+	return node, nil
+}
+
+// exampleComment documents a field from its tags
+func exampleComment(fieldStruct *reflect.StructField) string {
+	var lines []string
+	if desc := fieldStruct.Tag.Get("desc"); desc != "" {
+		lines = append(lines, desc)
+	}
+
+	var notes []string
+	required := fieldStruct.Tag.Get("required") == "true"
+	for _, rule := range strings.Split(fieldStruct.Tag.Get("validate"), ",") {
+		if rule == "dive" {
+			break
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			notes = append(notes, "one of: "+strings.Join(strings.Fields(param), ", "))
+		}
+	}
+	if required {
+		notes = append([]string{"required"}, notes...)
+	}
+	if defaultValue := fieldStruct.Tag.Get("default"); isDefaultExpression(defaultValue) {
+		notes = append(notes, "default: "+defaultValue)
+	}
+	if len(notes) > 0 {
+		lines = append(lines, "("+strings.Join(notes, ", ")+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeJSONNode writes a yaml node tree as indented json, keeping the order of keys
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close, step := "{", "}", 2
+		if node.Kind == yaml.SequenceNode {
+			open, close, step = "[", "]", 1
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(open + close)
+			return
+		}
+
+		buf.WriteString(open)
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString("\n" + indent + "  ")
+			if step == 2 {
+				key, _ := json.Marshal(node.Content[i].Value)
+				buf.Write(key)
+				buf.WriteString(": ")
+			}
+			writeJSONNode(buf, node.Content[i+step-1], indent+"  ")
+		}
+		buf.WriteString("\n" + indent + close)
+	default:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			value, _ := json.Marshal(node.Value)
+			buf.Write(value)
+		}
+	}
+}
+
+// writeTOMLTable writes the entries of a yaml mapping node as a toml table named by keys: values first,
+// then nested mappings as tables and sequences of mappings as arrays of tables.
+// Keys without a value are left out, as toml has no null
+func writeTOMLTable(buf *bytes.Buffer, node *yaml.Node, keys []string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isTOMLTable(value) || isTOMLArrayOfTables(value) || value.ShortTag() == "!!null" {
+			continue
+		}
+		writeTOMLComment(buf, key.HeadComment)
+		buf.WriteString(tomlKey(key.Value) + " = ")
+		writeTOMLValue(buf, value)
+		buf.WriteByte('\n')
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := append(keys[:len(keys):len(keys)], tomlKey(key.Value))
+		switch {
+		case isTOMLTable(value):
+			// tables of tables only are implied by their subtables
+			if key.HeadComment != "" || hasTOMLValues(value) {
+				writeTOMLHeader(buf, key.HeadComment, "["+strings.Join(path, ".")+"]")
+			}
+			writeTOMLTable(buf, value, path)
+		case isTOMLArrayOfTables(value):
+			for j, item := range value.Content {
+				comment := ""
+				if j == 0 {
+					comment = key.HeadComment
+				}
+				writeTOMLHeader(buf, comment, "[["+strings.Join(path, ".")+"]]")
+				writeTOMLTable(buf, item, path)
+			}
+		}
+	}
+}
+
+func writeTOMLHeader(buf *bytes.Buffer, comment string, header string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	writeTOMLComment(buf, comment)
+	buf.WriteString(header + "\n")
+}
+
+func writeTOMLComment(buf *bytes.Buffer, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString("# " + line + "\n")
+	}
+}
+
+// writeTOMLValue writes a yaml node as an inline toml value
+func writeTOMLValue(buf *bytes.Buffer, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].ShortTag() == "!!null" {
+				continue
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(" " + tomlKey(node.Content[i].Value) + " = ")
+			writeTOMLValue(buf, node.Content[i+1])
+		}
+		if len(node.Content) > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeTOMLValue(buf, item)
+		}
+		buf.WriteByte(']')
+	default:
+		switch node.ShortTag() {
+		case "!!int", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!float":
+			buf.WriteString(strings.TrimPrefix(strings.ToLower(node.Value), "."))
+		default:
+			value, _ := json.Marshal(node.Value)
+			buf.Write(value)
+		}
+	}
+}
+
+// isTOMLTable reports whether a yaml node is written as a toml table, rather than an inline value
+func isTOMLTable(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && len(node.Content) > 0
+}
+
+// hasTOMLValues reports whether a yaml mapping node has entries written as toml values, rather than tables
+func hasTOMLValues(node *yaml.Node) bool {
+	for i := 1; i < len(node.Content); i += 2 {
+		if value := node.Content[i]; !isTOMLTable(value) && !isTOMLArrayOfTables(value) && value.ShortTag() != "!!null" {
+			return true
+		}
+	}
+	return false
+}
+
+// isTOMLArrayOfTables reports whether a yaml node is written as a toml array of tables, rather than an inline array
+func isTOMLArrayOfTables(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey returns a toml key, quoted unless it is a bare key
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	quoted, _ := json.Marshal(key)
+	return string(quoted)
+}
+
+// TestingT is the part of testing.TB AssertExampleUpToDate uses
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertExampleUpToDate fails the test when the checked-in example configuration file differs from the
+// one GenerateExample returns for config, in the format of the file extension.
+// Run tests with CONFIGOR_UPDATE_EXAMPLES=true to rewrite stale examples instead
+func AssertExampleUpToDate(t TestingT, config interface{}, file string) {
+	t.Helper()
+
+	expected, err := GenerateExample(config, strings.TrimPrefix(path.Ext(file), "."))
+	if err != nil {
+		t.Errorf("failed to generate example %v: %v", file, err)
+		return
+	}
+
+	if os.Getenv("CONFIGOR_UPDATE_EXAMPLES") != "" {
+		if err := ioutil.WriteFile(file, expected, 0644); err != nil {
+			t.Errorf("failed to update example %v: %v", file, err)
+		}
+		return
+	}
+
+	if actual, err := ioutil.ReadFile(file); err != nil {
+		t.Errorf("failed to read example %v: %v", file, err)
+	} else if !bytes.Equal(actual, expected) {
+		t.Errorf("example %v is stale, run tests with CONFIGOR_UPDATE_EXAMPLES=true to update it. Expected:\n%s", file, expected)
+	}
+}
@@ -0,0 +1,152 @@
+package configor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type exampleServer struct {
+	Host string `desc:"Server host" validate:"required"`
+	Port int    `default:"8080"`
+}
+
+type exampleConfig struct {
+	Name     string   `default:"app" desc:"Name of the app"`
+	Mode     string   `default:"debug" validate:"oneof=debug release"`
+	Hostname string   `default:"${HOSTNAME}"`
+	MaxSize  ByteSize `default:"512MiB"`
+	Tags     []string `default:"[\"api\"]"`
+	Servers  []exampleServer
+	Pools    map[string]exampleServer
+	Secret   string `yaml:"-"`
+}
+
+func TestGenerateExample(t *testing.T) {
+	example, err := GenerateExample(&exampleConfig{}, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# Name of the app
+name: app
+# (one of: debug, release)
+mode: debug
+# (default: ${HOSTNAME})
+hostname: ""
+maxsize: 512MiB
+tags:
+  - api
+servers:
+  - # Server host
+    # (required)
+    host: ""
+    port: 8080
+pools:
+  example:
+    # Server host
+    # (required)
+    host: ""
+    port: 8080
+`
+	if string(example) != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, string(example))
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		example, err := GenerateExample(&exampleConfig{}, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := ioutil.TempFile("/tmp", "configor.*."+format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.Write(example)
+		file.Close()
+
+		var result exampleConfig
+		if err := New(&Config{ErrorOnUnmatchedKeys: true, Env: map[string]string{}}).Load(&result, file.Name()); err != nil {
+			t.Errorf("Example should load, but got %v", err)
+		}
+		expected := exampleConfig{Name: "app", Mode: "debug", MaxSize: 512 * MiB, Tags: []string{"api"},
+			Servers: []exampleServer{{Port: 8080}}, Pools: map[string]exampleServer{"example": {Port: 8080}}}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+		}
+	}
+
+	example, err = GenerateExample(&exampleConfig{}, "toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `# Name of the app
+name = "app"
+# (one of: debug, release)
+mode = "debug"
+# (default: ${HOSTNAME})
+hostname = ""
+maxsize = "512MiB"
+tags = ["api"]
+
+[[servers]]
+# Server host
+# (required)
+host = ""
+port = 8080
+
+[pools.example]
+# Server host
+# (required)
+host = ""
+port = 8080
+`
+	if string(example) != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, string(example))
+	}
+
+	if _, err := GenerateExample(&exampleConfig{}, "ini"); err == nil {
+		t.Errorf("Should get error for unsupported formats")
+	}
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertExampleUpToDate(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("name: app\n")
+	file.Close()
+
+	var stale fakeT
+	AssertExampleUpToDate(&stale, &exampleConfig{}, file.Name())
+	if len(stale.errors) != 1 {
+		t.Errorf("Stale example should fail, but got %v", stale.errors)
+	}
+
+	os.Setenv("CONFIGOR_UPDATE_EXAMPLES", "true")
+	var update fakeT
+	AssertExampleUpToDate(&update, &exampleConfig{}, file.Name())
+	os.Setenv("CONFIGOR_UPDATE_EXAMPLES", "")
+
+	var upToDate fakeT
+	AssertExampleUpToDate(&upToDate, &exampleConfig{}, file.Name())
+	if len(update.errors) > 0 || len(upToDate.errors) > 0 {
+		t.Errorf("Updated example should pass, but got %v %v", update.errors, upToDate.errors)
+	}
+}
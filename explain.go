@@ -0,0 +1,235 @@
+package configor
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin tells where the value of a configuration key was loaded from
+type Origin struct {
+	// Dotted key path, e.g. `db.port`
+	Key string
+	// Configuration file, ConfigMap key or env var the value was loaded from
+	Source string
+	// Profile of the overlay file or section the value was loaded from, if any
+	Profile string
+	// Position of the key in the configuration file, zero when unknown, e.g. for env vars
+	// or files with includes
+	Line   int
+	Column int
+}
+
+// String returns the origin in a human readable form
+func (origin Origin) String() string {
+	if origin.Profile != "" {
+		return fmt.Sprintf("%v: %v (profile %v)", origin.Key, origin.Source, origin.Profile)
+	}
+	return fmt.Sprintf("%v: %v", origin.Key, origin.Source)
+}
+
+// Explain returns where each configuration key was loaded from by the last Load, sorted by key
+func (configor *Configor) Explain() []Origin {
+	origins := make([]Origin, 0, len(configor.origins))
+	for _, origin := range configor.origins {
+		origins = append(origins, origin)
+	}
+	sort.Slice(origins, func(i, j int) bool { return origins[i].Key < origins[j].Key })
+	return origins
+}
+
+func (configor *Configor) recordOrigin(key, source, profile string) {
+	if configor.origins == nil {
+		configor.origins = map[string]Origin{}
+	}
+	key = strings.ToLower(key)
+	configor.origins[key] = Origin{Key: key, Source: source, Profile: profile}
+}
+
+// isPresent reports whether key, or any key nested in it, was loaded from a configuration source
+func (configor *Configor) isPresent(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := configor.origins[key]; ok {
+		return true
+	}
+	for k := range configor.origins {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// recordOrigins records source as the origin of every key found in data, at the position of the key
+// when positions is set. Keys are recorded by the path of the field of config they are decoded into
+func (configor *Configor) recordOrigins(config interface{}, data []byte, source, profile string, positions bool) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return
+	}
+	configor.recordNodeOrigins(&document, nil, reflect.TypeOf(config), nil, source, profile, positions, isJSONFile(source, data))
+}
+
+// recordNodeOrigins records source as the origin of every key of node, decoded into a value of typ.
+// keyNode is the key, or sequence item, node is the value of. Null values set nothing, so they are left out
+func (configor *Configor) recordNodeOrigins(node, keyNode *yaml.Node, typ reflect.Type, keys []string, source, profile string, positions, jsonFile bool) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			configor.recordNodeOrigins(content, nil, typ, keys, source, profile, positions, jsonFile)
+		}
+		return
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			break
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				configor.recordNodeOrigins(value, keyNode, typ, keys, source, profile, positions, jsonFile)
+				continue
+			}
+			configor.recordNodeOrigins(value, key, typ, append(keys[:len(keys):len(keys)], key.Value), source, profile, positions, jsonFile)
+		}
+		return
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			break
+		}
+		for i, item := range node.Content {
+			configor.recordNodeOrigins(item, item, typ, append(keys[:len(keys):len(keys)], fmt.Sprint(i)), source, profile, positions, jsonFile)
+		}
+		return
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return
+		}
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+	key := strings.Join(fieldKeyPath(typ, keys, jsonFile), ".")
+	configor.recordOrigin(key, source, profile)
+	if positions && keyNode != nil {
+		origin := configor.origins[strings.ToLower(key)]
+		origin.Line, origin.Column = keyNode.Line, keyNode.Column
+		configor.origins[origin.Key] = origin
+	}
+}
+
+// fieldKeyPath returns the key path of the field of typ that the keys of a configuration file are decoded into,
+// made of the getFieldKey names of struct fields, e.g. `DB.dbPort` of a json file is `db.db_port` for a field
+// tagged `json:"dbPort" yaml:"db_port"`. Keys are matched by the rules of yaml.v3, or encoding/json for json files,
+// and keys not matching any field are kept as is
+func fieldKeyPath(typ reflect.Type, keys []string, jsonFile bool) []string {
+	var result []string
+	for i, key := range keys {
+		if typ == nil {
+			return append(result, keys[i:]...)
+		}
+		typ = indirectType(typ)
+		switch typ.Kind() {
+		case reflect.Struct:
+			index, ok := fileFieldIndex(typ, key, jsonFile)
+			if !ok {
+				return append(result, keys[i:]...)
+			}
+			for _, j := range index {
+				fieldStruct := indirectType(typ).Field(j)
+				result = getKeysForField(result, &fieldStruct)
+				typ = fieldStruct.Type
+			}
+		case reflect.Slice, reflect.Array, reflect.Map:
+			result = append(result, key)
+			typ = typ.Elem()
+		default:
+			return append(result, keys[i:]...)
+		}
+	}
+	return result
+}
+
+// jsonFieldIndex returns the index of the field of a struct type decoded from key by encoding/json:
+// the field named key by its json tag or Go name, or else case-insensitively, including promoted fields
+func jsonFieldIndex(typ reflect.Type, key string) ([]int, bool) {
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return name == key },
+		func(name string) bool { return strings.EqualFold(name, key) },
+	} {
+		if index, ok := matchJSONField(typ, match); ok {
+			return index, true
+		}
+	}
+	return nil, false
+}
+
+func matchJSONField(typ reflect.Type, match func(name string) bool) ([]int, bool) {
+	var embedded [][]int
+	for i := 0; i < typ.NumField(); i++ {
+		fieldStruct := typ.Field(i)
+		tag := strings.Split(fieldStruct.Tag.Get("json"), ",")[0]
+		if tag == "-" || (!fieldStruct.IsExported() && !fieldStruct.Anonymous) {
+			continue
+		}
+		if tag == "" && fieldStruct.Anonymous && indirectType(fieldStruct.Type).Kind() == reflect.Struct {
+			// promoted fields are matched after the fields of typ
+			embedded = append(embedded, []int{i})
+			continue
+		}
+		if fieldStruct.IsExported() && match(jsonFieldName(&fieldStruct)) {
+			return []int{i}, true
+		}
+	}
+	for _, index := range embedded {
+		if promoted, ok := matchJSONField(indirectType(typ.Field(index[0]).Type), match); ok {
+			return append(index, promoted...), true
+		}
+	}
+	return nil, false
+}
+
+// recordTreeOrigins records source as the origin of every key of tree
+func (configor *Configor) recordTreeOrigins(tree interface{}, source, profile string) {
+	for _, key := range flattenTree(tree, nil) {
+		configor.recordOrigin(key, source, profile)
+	}
+}
+
+// getFileProfile returns the active profile file is an overlay for, if any
+func (configor *Configor) getFileProfile(file string) string {
+	base := strings.TrimSuffix(file, path.Ext(file))
+	for _, profile := range configor.GetProfiles() {
+		if strings.HasSuffix(base, "."+profile) {
+			return profile
+		}
+	}
+	return ""
+}
+
+// flattenTree returns the dotted key paths of all the leaves of tree
+func flattenTree(tree interface{}, prefixes []string) []string {
+	var keys []string
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			keys = append(keys, flattenTree(value, append(prefixes[:len(prefixes):len(prefixes)], key))...)
+		}
+	case []interface{}:
+		for i, value := range node {
+			keys = append(keys, flattenTree(value, append(prefixes[:len(prefixes):len(prefixes)], fmt.Sprint(i)))...)
+		}
+	}
+	if len(keys) == 0 && len(prefixes) > 0 {
+		keys = append(keys, strings.Join(prefixes, "."))
+	}
+	return keys
+}
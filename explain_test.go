@@ -0,0 +1,102 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/config.yaml", []byte("appname: configor\ndb:\n  name: configor\n  password: secret\n"), 0644)
+	ioutil.WriteFile(dir+"/config.production.yaml", []byte("db:\n  name: production_db\n  port: 5432\n"), 0644)
+	ioutil.WriteFile(dir+"/config.eu-west.yaml", []byte("db:\n  name: eu_west_db\n"), 0644)
+	ioutil.WriteFile(dir+"/config.canary.yaml", []byte("appname: canary\n"), 0644)
+
+	type config struct {
+		APPName string
+		DB      Database
+	}
+
+	os.Setenv("CONFIGOR_PROFILES", "production,eu-west,canary")
+	defer os.Setenv("CONFIGOR_PROFILES", "")
+	os.Setenv("CONFIGOR_DB_USER", "admin")
+	defer os.Setenv("CONFIGOR_DB_USER", "")
+
+	var result config
+	configor := New(&Config{ENVPrefix: "CONFIGOR"})
+	if err := configor.Load(&result, dir+"/config.yaml"); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		APPName: "canary",
+		DB:      Database{Name: "eu_west_db", User: "admin", Password: "secret", Port: 5432, SSL: true},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+
+	if env := configor.GetEnvironment(); env != "production" {
+		t.Errorf("Environment should be the first profile, instead got %v", env)
+	}
+
+	expectedOrigins := []Origin{
+		{Key: "appname", Source: dir + "/config.canary.yaml", Profile: "canary", Line: 1, Column: 1},
+		{Key: "db.name", Source: dir + "/config.eu-west.yaml", Profile: "eu-west", Line: 2, Column: 3},
+		{Key: "db.password", Source: dir + "/config.yaml", Line: 4, Column: 3},
+		{Key: "db.port", Source: dir + "/config.production.yaml", Profile: "production", Line: 3, Column: 3},
+		{Key: "db.user", Source: "env CONFIGOR_DB_USER"},
+	}
+	if origins := configor.Explain(); !reflect.DeepEqual(origins, expectedOrigins) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expectedOrigins, origins)
+	}
+}
+
+func TestProfilesPrecedence(t *testing.T) {
+	configor := New(&Config{Env: map[string]string{"CONFIGOR_PROFILES": "production, eu-west,,canary "}})
+	if expected := []string{"production", "eu-west", "canary"}; !reflect.DeepEqual(configor.GetProfiles(), expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, configor.GetProfiles())
+	}
+
+	configor = New(&Config{Environment: "production", Env: map[string]string{"CONFIGOR_PROFILES": "canary"}})
+	if expected := []string{"production"}; !reflect.DeepEqual(configor.GetProfiles(), expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, configor.GetProfiles())
+	}
+
+	// the environment overlay is loaded before the profiles it isn't part of
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(dir+"/config.yml", []byte("name: app\nregion: us\n"), 0644)
+	ioutil.WriteFile(dir+"/config.production.yml", []byte("name: production\nregion: us-east\n"), 0644)
+	ioutil.WriteFile(dir+"/config.eu-west.yml", []byte("region: eu-west\n"), 0644)
+
+	configor = New(&Config{Env: map[string]string{"CONFIGOR_ENV": "production", "CONFIGOR_PROFILES": "eu-west"}})
+	if expected := []string{"production", "eu-west"}; !reflect.DeepEqual(configor.GetProfiles(), expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, configor.GetProfiles())
+	}
+	var result struct {
+		Name   string
+		Region string
+	}
+	if err := configor.Load(&result, dir+"/config.yml"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "production" || result.Region != "eu-west" {
+		t.Errorf("Environment overlay should be loaded before the profiles, instead got %+v", result)
+	}
+
+	configor = New(&Config{Env: map[string]string{"CONFIGOR_ENV": "eu-west", "CONFIGOR_PROFILES": "production,eu-west"}})
+	if expected := []string{"production", "eu-west"}; !reflect.DeepEqual(configor.GetProfiles(), expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, configor.GetProfiles())
+	}
+}
@@ -0,0 +1,218 @@
+package configor
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// FileError is an error of a configuration key, located in the configuration file it was loaded from
+type FileError struct {
+	// Configuration file, ConfigMap key or env var the key was loaded from, if known
+	File string
+	// Position of the key in File, zero when unknown, e.g. for files with includes
+	Line   int
+	Column int
+	// Dotted key path, e.g. `db.port`
+	Key     string
+	Message string
+	// Err is the validator.FieldError of validation failures
+	Err error
+}
+
+// Error returns the error as `config.production.yml:14:3: db.port: cannot unmarshal !!str `abc` into int`
+func (err FileError) Error() string {
+	message := err.Message
+	if err.Key != "" {
+		message = err.Key + ": " + message
+	}
+	switch {
+	case err.Line > 0 && err.Column > 0:
+		return fmt.Sprintf("%v:%d:%d: %v", err.File, err.Line, err.Column, message)
+	case err.Line > 0:
+		return fmt.Sprintf("%v:%d: %v", err.File, err.Line, message)
+	case err.File != "":
+		return fmt.Sprintf("%v: %v", err.File, message)
+	}
+	return message
+}
+
+// Unwrap returns the validator.FieldError of validation failures
+func (err FileError) Unwrap() error {
+	return err.Err
+}
+
+// FileErrors are the type errors of a yaml configuration file, or validation failures located by
+// LocateErrors. Unwrap returns the underlying *yaml.TypeError or validator.ValidationErrors
+type FileErrors struct {
+	Errors []FileError
+	err    error
+}
+
+func (errs *FileErrors) Error() string {
+	messages := make([]string, 0, len(errs.Errors))
+	for _, err := range errs.Errors {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (errs *FileErrors) Unwrap() error {
+	return errs.err
+}
+
+// withoutPositions drops the positions of errs, when they refer to re-encoded content, e.g. of files with includes
+func withoutPositions(err error) error {
+	if errs, ok := err.(*FileErrors); ok {
+		for i := range errs.Errors {
+			errs.Errors[i].Line, errs.Errors[i].Column = 0, 0
+		}
+	}
+	return err
+}
+
+// nodePosition is the position of a key in a configuration file
+type nodePosition struct {
+	key          string
+	line, column int
+}
+
+// nodePositions returns the positions of the keys of node, and of the items of its block sequences,
+// parents first
+func nodePositions(node *yaml.Node, keys []string, positions []nodePosition) []nodePosition {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			positions = nodePositions(content, keys, positions)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				continue
+			}
+			path := append(keys[:len(keys):len(keys)], key.Value)
+			positions = append(positions, nodePosition{key: strings.Join(path, "."), line: key.Line, column: key.Column})
+			if value.Kind == yaml.ScalarNode && value.Line != key.Line {
+				positions = append(positions, nodePosition{key: strings.Join(path, "."), line: value.Line, column: value.Column})
+			}
+			positions = nodePositions(value, path, positions)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			path := append(keys[:len(keys):len(keys)], strconv.Itoa(i))
+			// items of flow sequences can't be told apart from the sequence
+			if node.Style&yaml.FlowStyle == 0 {
+				positions = append(positions, nodePosition{key: strings.Join(path, "."), line: item.Line, column: item.Column})
+			}
+			positions = nodePositions(item, path, positions)
+		}
+	}
+	return positions
+}
+
+var typeErrorLine = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// locateTypeErrors returns the errors of a yaml.TypeError decoding decoded, located by key in node
+// of file. decoded is node itself, or node re-encoded and parsed again
+func locateTypeErrors(file string, node, decoded *yaml.Node, typeErr *yaml.TypeError) error {
+	// nested keys win over their parents sharing a line, e.g. in flow mappings
+	lines := map[int]nodePosition{}
+	for _, position := range nodePositions(decoded, nil, nil) {
+		lines[position.line] = position
+	}
+	keys := map[string]nodePosition{}
+	for _, position := range nodePositions(node, nil, nil) {
+		if _, ok := keys[position.key]; !ok {
+			keys[position.key] = position
+		}
+	}
+
+	errs := &FileErrors{err: typeErr}
+	for _, message := range typeErr.Errors {
+		err := FileError{File: file, Message: message}
+		if match := typeErrorLine.FindStringSubmatch(message); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			err.Message = match[2]
+			if position, ok := lines[line]; ok {
+				err.Key = position.key
+				if position, ok := keys[position.key]; ok {
+					err.Line, err.Column = position.line, position.column
+				}
+			} else if decoded == node {
+				err.Line = line
+			}
+		}
+		errs.Errors = append(errs.Errors, err)
+	}
+	return errs
+}
+
+// LocateErrors returns the validation failures the last Load of config returned, located where their
+// keys were loaded from, e.g. `config.production.yml:14:3: db.port: failed on the 'min=1' validation`.
+// The result is FileErrors wrapping the validator.ValidationErrors, any other error is returned unchanged
+func (configor *Configor) LocateErrors(config interface{}, err error) error {
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	errs := &FileErrors{err: validationErrs}
+	for _, fieldErr := range validationErrs {
+		err := FileError{Key: validationKey(reflect.TypeOf(config), fieldErr.StructNamespace()), Err: fieldErr}
+		if fieldErr.Param() != "" {
+			err.Message = fmt.Sprintf("failed on the '%v=%v' validation", fieldErr.Tag(), fieldErr.Param())
+		} else {
+			err.Message = fmt.Sprintf("failed on the '%v' validation", fieldErr.Tag())
+		}
+		if origin, ok := configor.origins[strings.ToLower(err.Key)]; ok {
+			err.File, err.Line, err.Column = origin.Source, origin.Line, origin.Column
+		}
+		errs.Errors = append(errs.Errors, err)
+	}
+	return errs
+}
+
+// validationKey returns the dotted key path in configuration files of a field namespace
+// reported by the validator, e.g. `Config.DB.Hosts[0]` is `db.hosts.0`
+func validationKey(configType reflect.Type, namespace string) string {
+	var (
+		keys  []string
+		parts = strings.Split(namespace, ".")
+		typ   = indirectType(configType)
+	)
+	for _, part := range parts[1:] {
+		if typ.Kind() != reflect.Struct {
+			break
+		}
+		name, index, _ := strings.Cut(part, "[")
+		fieldStruct, ok := typ.FieldByName(name)
+		if !ok {
+			break
+		}
+		if !strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") {
+			keys = append(keys, getFieldKey(&fieldStruct))
+		}
+
+		typ = indirectType(fieldStruct.Type)
+		for index != "" {
+			// slice indexes and map keys, e.g. `Hosts[0]` or `Matrix[0][1]`
+			var key string
+			key, index, _ = strings.Cut(index, "]")
+			keys = append(keys, key)
+			index = strings.TrimPrefix(index, "[")
+			if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+				typ = indirectType(typ.Elem())
+			}
+		}
+	}
+	return strings.Join(keys, ".")
+}
@@ -0,0 +1,141 @@
+package configor
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type positionConfig struct {
+	Name string
+	DB   struct {
+		Host string
+		Port int `validate:"min=1"`
+	}
+	Hosts []string `validate:"dive,hostname"`
+}
+
+func TestFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/config.yml", []byte("name: configor\ndb:\n  host: localhost\n  port: 5432\n"), 0644)
+	ioutil.WriteFile(dir+"/config.production.yml", []byte("name: production\ndb:\n  port: abc\n  hots: typo\n"), 0644)
+
+	var result positionConfig
+	err = New(&Config{Environment: "production", ErrorOnUnmatchedKeys: true}).Load(&result, dir+"/config.yml")
+
+	var fileErrs *FileErrors
+	if !errors.As(err, &fileErrs) {
+		t.Fatalf("Error should be FileErrors, instead got %v", err)
+	}
+	expected := []FileError{
+		{File: dir + "/config.production.yml", Line: 3, Column: 3, Key: "db.port", Message: "cannot unmarshal !!str `abc` into int"},
+		{File: dir + "/config.production.yml", Line: 4, Column: 3, Key: "db.hots", Message: "field hots not found in type struct { Host string; Port int \"validate:\\\"min=1\\\"\" }"},
+	}
+	if !reflect.DeepEqual(fileErrs.Errors, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, fileErrs.Errors)
+	}
+	if expected := dir + "/config.production.yml:3:3: db.port: cannot unmarshal !!str `abc` into int"; fileErrs.Errors[0].Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, fileErrs.Errors[0].Error())
+	}
+}
+
+func TestFileErrorsSections(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("default:\n  name: configor\n  db:\n    port: 5432\nproduction:\n  db:\n    port: [1]\ndevelopment:\n  db:\n    port: abc\n")
+	file.Close()
+
+	var result positionConfig
+	err = New(&Config{Environment: "production", EnvironmentSections: true}).Load(&result, file.Name())
+	if expected := file.Name() + ":7:5: db.port: cannot unmarshal !!seq into int"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+
+	// sections are decoded on their own, so other top-level keys can be anything
+	strict, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(strict.Name())
+	strict.WriteString("\"a,b\": 1\n\"-\": 2\nproduction:\n  name: production\n  nmae: typo\n")
+	strict.Close()
+
+	result = positionConfig{}
+	err = New(&Config{Environment: "production", EnvironmentSections: true, ErrorOnUnmatchedKeys: true}).Load(&result, strict.Name())
+	if expected := strict.Name() + ":5:3: nmae: field nmae not found in type configor.positionConfig"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+
+	result = positionConfig{}
+	if err := New(&Config{Environment: "test", EnvironmentSections: true}).Load(&result, file.Name()); err != nil {
+		t.Errorf("No error should happen when the broken sections are not active, but got %v", err)
+	}
+	if result.Name != "configor" || result.DB.Port != 5432 {
+		t.Errorf("Sections should be decoded, instead got %+v", result)
+	}
+}
+
+func TestFileErrorsValidation(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("name: configor\ndb:\n  port: -1\nhosts:\n- db.example.org\n- not a host\n")
+	file.Close()
+
+	var result positionConfig
+	configor := New(&Config{})
+	err = configor.Load(&result, file.Name())
+	if _, ok := err.(validator.ValidationErrors); !ok {
+		t.Fatalf("Error should be validator.ValidationErrors, instead got %v", err)
+	}
+
+	err = configor.LocateErrors(&result, err)
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 2 {
+		t.Fatalf("Error should wrap the validation errors, instead got %v", err)
+	}
+	expected := file.Name() + ":3:3: db.port: failed on the 'min=1' validation\n" +
+		file.Name() + ":6:3: hosts.1: failed on the 'hostname' validation"
+	if err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+
+	// keys not loaded from files are located by key only
+	result = positionConfig{}
+	configor = New(&Config{})
+	err = configor.LocateErrors(&result, configor.Load(&result))
+	if expected := "db.port: failed on the 'min=1' validation"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+}
+
+func TestFileErrorsIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/db.yml", []byte("port: abc\n"), 0644)
+	ioutil.WriteFile(dir+"/config.yml", []byte("name: configor\ndb:\n  $import: db.yml\n"), 0644)
+
+	var result positionConfig
+	err = New(&Config{}).Load(&result, dir+"/config.yml")
+	if expected := dir + "/config.yml: db.port: cannot unmarshal !!str `abc` into int"; err == nil || err.Error() != expected {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, err)
+	}
+}
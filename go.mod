@@ -1,11 +1,21 @@
 module github.com/xmlking/configor
 
-go 1.15
+go 1.18
 
 require (
 	github.com/creasty/defaults v1.5.1
 	github.com/go-playground/validator/v10 v10.4.1
 	github.com/markbates/pkger v0.17.1
 	github.com/stoewer/go-strcase v1.2.0
-	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/gobuffalo/here v0.6.0 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9 // indirect
+	golang.org/x/sys v0.0.0-20190412213103-97732733099d // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,173 @@
+package configor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// includeDirective pulls other files in at the root of a configuration file
+	includeDirective = "$include"
+	// importDirective pulls other files in at the key of the mapping it is found in
+	importDirective = "$import"
+)
+
+// hasIncludes is a cheap check whether data may contain include directives
+func hasIncludes(data []byte) bool {
+	return bytes.Contains(data, []byte(includeDirective)) || bytes.Contains(data, []byte(importDirective))
+}
+
+// processIncludes resolves the include directives of file, and returns its
+// content re-encoded in the file's own format
+func (configor *Configor) processIncludes(file string, data []byte) ([]byte, error) {
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil || !hasDirective(normalizeTree(tree), true) {
+		// leave reporting syntax errors to decode
+		return data, nil
+	}
+
+	tree, err := configor.resolveIncludes(file, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(file, ".json") {
+		return json.Marshal(tree)
+	}
+	return yaml.Marshal(tree)
+}
+
+// hasDirective reports whether tree contains an include directive
+func hasDirective(tree interface{}, root bool) bool {
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		if _, ok := node[importDirective]; ok {
+			return true
+		}
+		if _, ok := node[includeDirective]; ok && root {
+			return true
+		}
+		for _, value := range node {
+			if hasDirective(value, false) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range node {
+			if hasDirective(value, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveIncludes decodes data of file into a tree and replaces its include directives
+// with the content of the files they point to. chain holds the files including file
+func (configor *Configor) resolveIncludes(file string, data []byte, chain []string) (interface{}, error) {
+	chain = append(chain, path.Clean(file))
+
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("%v (include chain: %v)", err, strings.Join(chain, " -> "))
+	}
+	tree = normalizeTree(tree)
+
+	if root, ok := tree.(map[string]interface{}); ok {
+		if _, ok := root[includeDirective]; ok {
+			return configor.expandDirective(root, includeDirective, file, chain)
+		}
+	}
+	return configor.expandImports(tree, file, chain)
+}
+
+// expandImports replaces `$import` directives found anywhere in tree
+func (configor *Configor) expandImports(tree interface{}, file string, chain []string) (interface{}, error) {
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		if _, ok := node[importDirective]; ok {
+			return configor.expandDirective(node, importDirective, file, chain)
+		}
+		for key, value := range node {
+			expanded, err := configor.expandImports(value, file, chain)
+			if err != nil {
+				return nil, err
+			}
+			node[key] = expanded
+		}
+	case []interface{}:
+		for i, value := range node {
+			expanded, err := configor.expandImports(value, file, chain)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = expanded
+		}
+	}
+	return tree, nil
+}
+
+// expandDirective merges the files listed by directive in node, in order, and then
+// the remaining keys of node over them, so the including file has the last word
+func (configor *Configor) expandDirective(node map[string]interface{}, directive, file string, chain []string) (interface{}, error) {
+	var includes []string
+	switch value := node[directive].(type) {
+	case string:
+		includes = []string{value}
+	case []interface{}:
+		for _, include := range value {
+			includes = append(includes, fmt.Sprint(include))
+		}
+	default:
+		return nil, fmt.Errorf("invalid %v directive %v in %v, should be a file name or a list of file names", directive, value, file)
+	}
+	delete(node, directive)
+
+	var result interface{} = map[string]interface{}{}
+	for _, include := range includes {
+		if !path.IsAbs(include) {
+			include = path.Join(path.Dir(file), include)
+		}
+
+		for _, f := range chain {
+			if f == include {
+				return nil, fmt.Errorf("include cycle detected: %v -> %v", strings.Join(chain, " -> "), include)
+			}
+		}
+
+		if configor.Config.Debug || configor.Config.Verbose {
+			fmt.Printf("Including configurations from file '%v' in '%v'...\n", include, file)
+		}
+
+		data, err := readFile(include, configor.UsePkger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %v (include chain: %v): %v", include, strings.Join(chain, " -> "), err)
+		}
+		included, err := configor.resolveIncludes(include, data, chain)
+		if err != nil {
+			return nil, err
+		}
+		result = mergeTree(result, included)
+	}
+
+	// sort keys so nested imports are resolved in a stable order
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		expanded, err := configor.expandImports(node[key], file, chain)
+		if err != nil {
+			return nil, err
+		}
+		node[key] = expanded
+	}
+	return mergeTree(result, node), nil
+}
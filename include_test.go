@@ -0,0 +1,80 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncludeDirectives(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Mkdir(dir+"/shared", 0755)
+	ioutil.WriteFile(dir+"/config.yaml", []byte("$include: shared/common.yaml\nappname: service\ndb:\n  $import: shared/db.json\n  name: service_db\n"), 0644)
+	ioutil.WriteFile(dir+"/shared/common.yaml", []byte("appname: common\nhosts:\n- http://example.org\n"), 0644)
+	ioutil.WriteFile(dir+"/shared/db.json", []byte(`{"name": "common_db", "password": "secret", "port": 5432}`), 0644)
+
+	type config struct {
+		APPName string
+		Hosts   []string
+		DB      Database
+	}
+
+	var result config
+	if err := Load(&result, dir+"/config.yaml"); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	expected := config{
+		APPName: "service",
+		Hosts:   []string{"http://example.org"},
+		DB:      Database{Name: "service_db", User: "root", Password: "secret", Port: 5432, SSL: true},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(dir+"/a.yaml", []byte("$include: b.yaml\n"), 0644)
+	ioutil.WriteFile(dir+"/b.yaml", []byte("db:\n  $import: a.yaml\n"), 0644)
+
+	var result struct{ DB Database }
+	err = Load(&result, dir+"/a.yaml")
+	if err == nil {
+		t.Fatal("Should get error when files include each other")
+	}
+	if chain := dir + "/a.yaml -> " + dir + "/b.yaml -> " + dir + "/a.yaml"; !strings.Contains(err.Error(), chain) {
+		t.Errorf("Error should show the include chain %v, instead error is %v", chain, err)
+	}
+}
+
+func TestIncludeField(t *testing.T) {
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("include: shared/common.yaml\n")
+	file.Close()
+
+	var result struct{ Include string }
+	if err := Load(&result, file.Name()); err != nil {
+		t.Fatalf("A plain include key should be decoded as a field, but got %v", err)
+	}
+	if result.Include != "shared/common.yaml" {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", "shared/common.yaml", result.Include)
+	}
+}
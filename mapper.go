@@ -0,0 +1,70 @@
+package configor
+
+import (
+	"strings"
+
+	"github.com/stoewer/go-strcase"
+)
+
+// NameMapper maps the path of a struct field, starting with the ENV prefix, to an env var name.
+// e.g. `[]string{"Configor", "DB", "Name"}` to `CONFIGOR_DB_NAME`
+type NameMapper func(path []string, separator string) string
+
+// UpperSnakeCaseMapper maps field path `Configor`, `DB`, `Name` to `CONFIGOR_DB_NAME`
+func UpperSnakeCaseMapper(path []string, separator string) string {
+	return mapPath(path, separator, strcase.UpperSnakeCase)
+}
+
+// ExactMapper maps field path `Configor`, `DB`, `Name` to `Configor_DB_Name`
+func ExactMapper(path []string, separator string) string {
+	return strings.Join(path, separator)
+}
+
+// UpperCaseMapper maps field path `Configor`, `DB`, `Name` to `CONFIGOR_DB_NAME` and `APPName` to `APPNAME`
+func UpperCaseMapper(path []string, separator string) string {
+	return mapPath(path, separator, strings.ToUpper)
+}
+
+// KebabCaseMapper maps field path `Configor`, `DB`, `Name` to `configor-db-name` when separator is `-`
+func KebabCaseMapper(path []string, separator string) string {
+	return mapPath(path, separator, strcase.KebabCase)
+}
+
+func mapPath(path []string, separator string, mapper func(string) string) string {
+	names := make([]string, len(path))
+	for i, name := range path {
+		names[i] = mapper(name)
+	}
+	return strings.Join(names, separator)
+}
+
+func (configor *Configor) getENVSeparator() string {
+	if configor.Config.ENVSeparator == "" {
+		if separator := configor.getenv("CONFIGOR_ENV_SEPARATOR"); separator != "" {
+			return separator
+		}
+		return "_"
+	}
+	return configor.Config.ENVSeparator
+}
+
+// getENVNames returns the env var names a field with the given path is loaded from, in priority order
+func (configor *Configor) getENVNames(path []string) []string {
+	mappers := configor.Config.ENVNameMappers
+	if len(mappers) == 0 {
+		mappers = []NameMapper{UpperSnakeCaseMapper}
+	}
+
+	var envNames []string
+	for _, mapper := range mappers {
+		envName := mapper(path, configor.getENVSeparator())
+		duplicated := false
+		for _, name := range envNames {
+			duplicated = duplicated || name == envName
+		}
+		if !duplicated {
+			envNames = append(envNames, envName)
+		}
+	}
+	return envNames
+}
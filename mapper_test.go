@@ -0,0 +1,69 @@
+package configor
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestENVNameMappers(t *testing.T) {
+	type config struct {
+		APPName string
+		DB      struct {
+			Host string
+			Port uint
+		}
+		Servers []struct {
+			Name string
+		}
+	}
+
+	os.Setenv("MYAPP__APP_NAME", "myapp")
+	os.Setenv("MYAPP__DB__HOST", "localhost")
+	os.Setenv("myapp__DB__Port", "5432")
+	os.Setenv("MYAPP__SERVERS__0__NAME", "server0")
+	defer os.Setenv("MYAPP__APP_NAME", "")
+	defer os.Setenv("MYAPP__DB__HOST", "")
+	defer os.Setenv("myapp__DB__Port", "")
+	defer os.Setenv("MYAPP__SERVERS__0__NAME", "")
+
+	var result config
+	err := New(&Config{
+		ENVPrefix:      "myapp",
+		ENVSeparator:   "__",
+		ENVNameMappers: []NameMapper{UpperSnakeCaseMapper, ExactMapper},
+	}).Load(&result)
+	if err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+
+	var expected config
+	expected.APPName = "myapp"
+	expected.DB.Host = "localhost"
+	expected.DB.Port = 5432
+	expected.Servers = append(expected.Servers, struct{ Name string }{"server0"})
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+}
+
+func TestCustomNameMapper(t *testing.T) {
+	path := []string{"Configor", "DB", "Name"}
+	var tests = []struct {
+		mapper    NameMapper
+		separator string
+		expected  string
+	}{
+		{UpperSnakeCaseMapper, "_", "CONFIGOR_DB_NAME"},
+		{ExactMapper, "_", "Configor_DB_Name"},
+		{UpperCaseMapper, "__", "CONFIGOR__DB__NAME"},
+		{KebabCaseMapper, "-", "configor-db-name"},
+		{func(path []string, separator string) string { return strings.ToLower(strings.Join(path, separator)) }, ".", "configor.db.name"},
+	}
+	for _, test := range tests {
+		if name := test.mapper(path, test.separator); name != test.expected {
+			t.Errorf("Env name should be %v, instead got %v", test.expected, name)
+		}
+	}
+}
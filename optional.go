@@ -0,0 +1,101 @@
+package configor
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Optional holds a value that may be missing from all configuration sources,
+// telling an explicit `false` or `0` apart from a missing value
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns an Optional set to value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// Get returns the value, and whether it was set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// IsSet reports whether the value was set by any configuration source
+func (o Optional[T]) IsSet() bool {
+	return o.Set
+}
+
+// Or returns the value if it was set, and fallback otherwise
+func (o Optional[T]) Or(fallback T) T {
+	if o.Set {
+		return o.Value
+	}
+	return fallback
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (o *Optional[T]) UnmarshalYAML(node *yaml.Node) error {
+	if err := node.Decode(&o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, for env vars and `default` tags
+func (o *Optional[T]) UnmarshalText(text []byte) error {
+	if value := reflect.ValueOf(&o.Value).Elem(); value.Kind() == reflect.String {
+		value.SetString(string(text))
+	} else if err := yaml.Unmarshal(text, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// setOptional marks the value as set, and returns it for configor to decode env values into
+func (o *Optional[T]) setOptional() reflect.Value {
+	o.Set = true
+	return reflect.ValueOf(&o.Value).Elem()
+}
+
+// MarshalYAML implements yaml.Marshaler, a missing value is marshaled as null
+func (o Optional[T]) MarshalYAML() (interface{}, error) {
+	if !o.Set {
+		return nil, nil
+	}
+	return o.Value, nil
+}
+
+// MarshalJSON implements json.Marshaler, a missing value is marshaled as null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// isRequiredSet reports whether a required field was set, by any configuration source for the given key,
+// or programmatically before Load. Nil pointers and unset Optional values are always missing
+func (configor *Configor) isRequiredSet(field reflect.Value, key string) bool {
+	if field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		return !field.IsNil()
+	}
+	if optional, ok := field.Interface().(interface{ IsSet() bool }); ok {
+		return optional.IsSet()
+	}
+	return configor.isPresent(key) || !field.IsZero()
+}
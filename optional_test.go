@@ -0,0 +1,113 @@
+package configor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRequiredDistinguishesUnsetFromZero(t *testing.T) {
+	type config struct {
+		Port    int            `required:"true"`
+		Debug   *bool          `required:"true"`
+		Verbose Optional[bool] `required:"true"`
+		Workers Optional[int]  `default:"4"`
+		Name    Optional[string]
+	}
+
+	var tests = []struct {
+		content string
+		env     map[string]string
+		valid   bool
+	}{
+		{`{"Port": 0, "Debug": false, "Verbose": false}`, nil, true},
+		{`{"Debug": false, "Verbose": false}`, nil, false},
+		{`{"Port": 0, "Verbose": false}`, nil, false},
+		{`{"Port": 0, "Debug": false}`, nil, false},
+		{`{"Port": 0, "Debug": false}`, map[string]string{"CONFIGOR_VERBOSE": "false"}, true},
+		{`{}`, map[string]string{"CONFIGOR_PORT": "0", "CONFIGOR_DEBUG": "false", "CONFIGOR_VERBOSE": "0"}, true},
+	}
+
+	for _, test := range tests {
+		file, err := ioutil.TempFile("/tmp", "configor.*.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.WriteString(test.content)
+		file.Close()
+
+		var result config
+		err = New(&Config{Env: test.env}).Load(&result, file.Name())
+		if test.valid && err != nil {
+			t.Errorf("No error should happen when loading %v with env %v, but got %v", test.content, test.env, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("Should get error when loading %v with env %v", test.content, test.env)
+		}
+
+		if test.valid {
+			if value, ok := result.Verbose.Get(); !ok || value {
+				t.Errorf("Verbose should be explicitly false, instead got %+v", result.Verbose)
+			}
+			if workers := result.Workers.Or(1); workers != 4 {
+				t.Errorf("Workers should be set by default tag, instead got %v", workers)
+			}
+			if result.Name.IsSet() {
+				t.Errorf("Name should be missing, instead got %+v", result.Name)
+			}
+		}
+	}
+}
+
+func TestRequiredFileKeys(t *testing.T) {
+	type config struct {
+		DB struct {
+			Port int `json:"dbPort" yaml:"db_port" required:"true"`
+		}
+	}
+
+	var tests = []struct {
+		ext     string
+		content string
+		valid   bool
+	}{
+		{"json", `{"DB": {"dbPort": 0}}`, true},
+		{"json", `{"db": {"DBPORT": 0}}`, true},
+		{"json", `{"DB": {"dbPort": null}}`, false},
+		{"yml", "db:\n  db_port: 0\n", true},
+		{"yml", "db:\n  db_port:\n", false},
+		{"yml", "db:\n  dbPort: 0\n", false},
+	}
+
+	for _, test := range tests {
+		file, err := ioutil.TempFile("/tmp", "configor.*."+test.ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.WriteString(test.content)
+		file.Close()
+
+		var result config
+		err = New(&Config{Env: map[string]string{}}).Load(&result, file.Name())
+		if test.valid && err != nil {
+			t.Errorf("No error should happen when loading %v, but got %v", test.content, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("Should get error when loading %v", test.content)
+		}
+	}
+}
+
+func TestMarshalOptional(t *testing.T) {
+	value := struct {
+		Set   Optional[int]
+		Unset Optional[int]
+	}{Set: Some(0)}
+
+	if data, err := json.Marshal(value); err != nil {
+		t.Error(err)
+	} else if expected := `{"Set":0,"Unset":null}`; string(data) != expected {
+		t.Errorf("\nExpected: %v, \nGot: %v", expected, string(data))
+	}
+}
@@ -0,0 +1,394 @@
+package configor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaDraft is the JSON Schema dialect of generated schemas
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var optionalType = reflect.TypeOf((*interface{ setOptional() reflect.Value })(nil)).Elem()
+
+// Schema is a JSON Schema document, or one of its subschemas.
+// Only the keywords configor generates and validates are supported
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Description string             `json:"description,omitempty"`
+
+	// Type is a single type, e.g. `string`, or several, e.g. `string` and `integer` for durations
+	Type   []string      `json:"-"`
+	Format string        `json:"format,omitempty"`
+	Enum   []interface{} `json:"enum,omitempty"`
+
+	Default interface{} `json:"default,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int     `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	MinItems         *int     `json:"minItems,omitempty"`
+	MaxItems         *int     `json:"maxItems,omitempty"`
+	MinProperties    *int     `json:"minProperties,omitempty"`
+	MaxProperties    *int     `json:"maxProperties,omitempty"`
+
+	// YAMLName and JSONName are the keys of a property in yaml and json files, when they differ from its name
+	YAMLName string `json:"x-yaml-name,omitempty"`
+	JSONName string `json:"x-json-name,omitempty"`
+	// GoName is the name of the struct field of a property, when it differs from its name, which env var
+	// names are made of, and EnvName the env var of fields with an `env` tag
+	GoName  string `json:"x-go-name,omitempty"`
+	EnvName string `json:"x-env,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	// Bool is set for the `true` and `false` schemas, which accept anything and nothing
+	Bool *bool `json:"-"`
+}
+
+type schemaAlias Schema
+
+// MarshalJSON implements json.Marshaler
+func (schema *Schema) MarshalJSON() ([]byte, error) {
+	if schema.Bool != nil {
+		return json.Marshal(*schema.Bool)
+	}
+
+	// keep `$schema` and `type` first, it's what readers look for
+	var leading [][]byte
+	if schema.Schema != "" {
+		data, _ := json.Marshal(schema.Schema)
+		leading = append(leading, append([]byte(`"$schema":`), data...))
+	}
+	if len(schema.Type) > 0 {
+		var typ interface{} = schema.Type
+		if len(schema.Type) == 1 {
+			typ = schema.Type[0]
+		}
+		data, _ := json.Marshal(typ)
+		leading = append(leading, append([]byte(`"type":`), data...))
+	}
+
+	rest := *schema
+	rest.Schema = ""
+	data, err := json.Marshal((*schemaAlias)(&rest))
+	if err != nil {
+		return nil, err
+	}
+	if fields := data[1 : len(data)-1]; len(fields) > 0 {
+		leading = append(leading, fields)
+	}
+	return append(append([]byte("{"), bytes.Join(leading, []byte(","))...), '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (schema *Schema) UnmarshalJSON(data []byte) error {
+	var boolean bool
+	if err := json.Unmarshal(data, &boolean); err == nil {
+		*schema = Schema{Bool: &boolean}
+		return nil
+	}
+
+	var typed struct {
+		Type interface{} `json:"type"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*schemaAlias)(schema)); err != nil {
+		return err
+	}
+
+	switch typ := typed.Type.(type) {
+	case string:
+		schema.Type = []string{typ}
+	case []interface{}:
+		for _, t := range typ {
+			schema.Type = append(schema.Type, fmt.Sprint(t))
+		}
+	}
+	return nil
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the configuration files of config, e.g. for
+// editors to complete and lint them. Keys are named after yaml and json tags, with `x-yaml-name` and `x-json-name`
+// keywords when yaml and json files name them differently, and `x-go-name` and `x-env` keywords for the env var
+// names of fields. `default`, `required`, `desc` and `validate` tags are translated to keywords (min, max, len,
+// oneof, email, url...)
+func JSONSchema(config interface{}) *Schema {
+	generator := schemaGenerator{names: map[reflect.Type]string{}, defs: map[string]*Schema{}}
+
+	schema := generator.structSchema(indirectType(reflect.TypeOf(config)))
+	schema.Schema = schemaDraft
+	if len(generator.defs) > 0 {
+		schema.Defs = generator.defs
+	}
+	return schema
+}
+
+// schemaGenerator generates schemas of config types, named struct types are generated once into `$defs`
+type schemaGenerator struct {
+	names map[reflect.Type]string
+	defs  map[string]*Schema
+}
+
+func (generator *schemaGenerator) typeSchema(typ reflect.Type) *Schema {
+	if typ.Kind() == reflect.Ptr {
+		return generator.typeSchema(typ.Elem())
+	}
+
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		return &Schema{Type: []string{"string"}, Format: "date-time"}
+	case typ == reflect.TypeOf(time.Duration(0)) || typ == reflect.TypeOf(Duration(0)):
+		return &Schema{Type: []string{"string", "integer"}}
+	case typ == reflect.TypeOf(ByteSize(0)):
+		return &Schema{Type: []string{"string", "integer"}, Minimum: floatPtr(0)}
+	case typ == reflect.TypeOf(Percent(0)):
+		return &Schema{Type: []string{"string", "number"}}
+	case reflect.PtrTo(typ).Implements(optionalType):
+		valueField, _ := typ.FieldByName("Value")
+		return generator.typeSchema(valueField.Type)
+	case typ.Kind() == reflect.Struct && isLeafType(typ):
+		// decoded from text, such as Rate
+		return &Schema{Type: []string{"string"}}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: []string{"integer"}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: []string{"integer"}, Minimum: floatPtr(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: []string{"number"}}
+	case reflect.String:
+		return &Schema{Type: []string{"string"}}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: []string{"string"}}
+		}
+		return &Schema{Type: []string{"array"}, Items: generator.typeSchema(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object"}, AdditionalProperties: generator.typeSchema(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return generator.structSchema(typ)
+		}
+		name, ok := generator.names[typ]
+		if !ok {
+			name = typ.Name()
+			for i := 2; generator.defs[name] != nil; i++ {
+				name = fmt.Sprintf("%v%d", typ.Name(), i)
+			}
+			generator.names[typ] = name
+			// reserve the name before generating, for recursive types
+			generator.defs[name] = &Schema{}
+			*generator.defs[name] = *generator.structSchema(typ)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	}
+	// interfaces, funcs...
+	return &Schema{}
+}
+
+func (generator *schemaGenerator) structSchema(typ reflect.Type) *Schema {
+	schema := &Schema{Type: []string{"object"}, Properties: map[string]*Schema{}, AdditionalProperties: &Schema{Bool: new(bool)}}
+	if typ.Kind() != reflect.Struct {
+		return generator.typeSchema(typ)
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		fieldStruct := typ.Field(i)
+		if !fieldStruct.IsExported() || fieldStruct.Tag.Get("yaml") == "-" || (fieldStruct.Tag.Get("yaml") == "" && fieldStruct.Tag.Get("json") == "-") {
+			continue
+		}
+
+		if strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") {
+			inline := generator.structSchema(indirectType(fieldStruct.Type))
+			for name, property := range inline.Properties {
+				schema.Properties[name] = property
+			}
+			schema.Required = append(schema.Required, inline.Required...)
+			continue
+		}
+
+		var (
+			name     = getFieldKey(&fieldStruct)
+			property = generator.typeSchema(fieldStruct.Type)
+		)
+		if property.Ref != "" {
+			// keywords next to `$ref` would change the shared definition
+			property = &Schema{Ref: property.Ref}
+		}
+		if yamlName := yamlFieldName(&fieldStruct); yamlName != name {
+			property.YAMLName = yamlName
+		}
+		if jsonName := jsonFieldName(&fieldStruct); !strings.EqualFold(jsonName, name) {
+			property.JSONName = jsonName
+		}
+		if fieldStruct.Name != name {
+			property.GoName = fieldStruct.Name
+		}
+		property.EnvName = fieldStruct.Tag.Get("env")
+		property.Description = fieldStruct.Tag.Get("desc")
+		property.Default = schemaDefault(property, fieldStruct.Tag.Get("default"))
+		if translateValidateRules(property, fieldStruct.Tag.Get("validate")) || fieldStruct.Tag.Get("required") == "true" {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// schemaDefault converts a `default` tag to a value of the schema's type, literals of objects and arrays are json.
+// Default expressions are left out, their value is only known when loading
+func schemaDefault(schema *Schema, defaultValue string) interface{} {
+	if defaultValue == "" || defaultValue == "-" || isDefaultExpression(defaultValue) {
+		return nil
+	}
+	defaultValue = literalDefault(defaultValue)
+
+	switch {
+	case schema.hasType("string"):
+		return defaultValue
+	case schema.hasType("boolean"):
+		if value, err := strconv.ParseBool(defaultValue); err == nil {
+			return value
+		}
+	case schema.hasType("integer"), schema.hasType("number"):
+		if value, err := strconv.ParseFloat(defaultValue, 64); err == nil {
+			return value
+		}
+	default:
+		var value interface{}
+		if err := json.Unmarshal([]byte(defaultValue), &value); err == nil {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+// translateValidateRules translates the validator rules of a `validate` tag into keywords of schema,
+// and reports whether the field is required. Rules after `dive` apply to the items of slices and maps
+func translateValidateRules(schema *Schema, rules string) (required bool) {
+	target := schema
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		number, numberErr := strconv.ParseFloat(param, 64)
+		count, countErr := strconv.Atoi(param)
+
+		switch name {
+		case "required":
+			required = target == schema
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+			} else if target.AdditionalProperties != nil && target.AdditionalProperties.Bool == nil {
+				target = target.AdditionalProperties
+			} else {
+				return required
+			}
+		case "min", "gte", "max", "lte", "len", "gt", "lt":
+			if numberErr != nil {
+				continue
+			}
+			if target.hasType("integer") || target.hasType("number") {
+				switch name {
+				case "min", "gte":
+					target.Minimum = floatPtr(number)
+				case "max", "lte":
+					target.Maximum = floatPtr(number)
+				case "gt":
+					target.ExclusiveMinimum = floatPtr(number)
+				case "lt":
+					target.ExclusiveMaximum = floatPtr(number)
+				case "len":
+					target.Minimum, target.Maximum = floatPtr(number), floatPtr(number)
+				}
+				continue
+			}
+
+			if countErr != nil {
+				continue
+			}
+			var minimum, maximum **int
+			switch {
+			case target.hasType("string"):
+				minimum, maximum = &target.MinLength, &target.MaxLength
+			case target.hasType("array"):
+				minimum, maximum = &target.MinItems, &target.MaxItems
+			case target.hasType("object"):
+				minimum, maximum = &target.MinProperties, &target.MaxProperties
+			default:
+				continue
+			}
+			switch name {
+			case "min", "gte":
+				*minimum = &count
+			case "gt":
+				*minimum = intPtr(count + 1)
+			case "max", "lte":
+				*maximum = &count
+			case "lt":
+				*maximum = intPtr(count - 1)
+			case "len":
+				*minimum, *maximum = &count, &count
+			}
+		case "oneof":
+			target.Enum = nil
+			for _, option := range strings.Fields(param) {
+				if target.hasType("integer") || target.hasType("number") {
+					if value, err := strconv.ParseFloat(option, 64); err == nil {
+						target.Enum = append(target.Enum, value)
+						continue
+					}
+				}
+				target.Enum = append(target.Enum, option)
+			}
+		case "email":
+			target.Format = "email"
+		case "url", "uri":
+			target.Format = "uri"
+		case "hostname", "hostname_rfc1123":
+			target.Format = "hostname"
+		case "ipv4", "ipv6", "uuid":
+			target.Format = name
+		case "datetime":
+			target.Format = "date-time"
+		}
+	}
+	return required
+}
+
+func (schema *Schema) hasType(typ string) bool {
+	for _, t := range schema.Type {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func intPtr(value int) *int {
+	return &value
+}
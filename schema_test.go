@@ -0,0 +1,81 @@
+package configor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type schemaServer struct {
+	Host string `validate:"required,hostname" desc:"Server host"`
+	Port int    `default:"8080" validate:"min=1,max=65535"`
+}
+
+type schemaConfig struct {
+	Name     string            `yaml:"app_name" default:"app" validate:"min=3,max=32"`
+	Mode     string            `json:"mode" validate:"oneof=debug release"`
+	Email    string            `validate:"email"`
+	Endpoint string            `validate:"url"`
+	Level    int               `validate:"oneof=1 2 3"`
+	Timeout  Duration          `default:"30s"`
+	Workers  Optional[int]     `required:"true"`
+	Tags     []string          `default:"[\"a\"]" validate:"min=1,dive,min=2"`
+	Labels   map[string]string `default:"{\"team\": \"core\"}"`
+	Server   schemaServer
+	Replicas []*schemaServer
+	Host     string `default:"${HOSTNAME}"`
+	Secret   string `yaml:"-"`
+	private  string
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := json.Marshal(JSONSchema(&schemaConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result, expected interface{}
+	json.Unmarshal(data, &result)
+	json.Unmarshal([]byte(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "$defs": {
+    "schemaServer": {
+      "type": "object",
+      "properties": {
+        "host": {"type": "string", "description": "Server host", "format": "hostname", "x-go-name": "Host"},
+        "port": {"type": "integer", "default": 8080, "minimum": 1, "maximum": 65535, "x-go-name": "Port"}
+      },
+      "additionalProperties": false,
+      "required": ["host"]
+    }
+  },
+  "properties": {
+    "app_name": {"type": "string", "default": "app", "minLength": 3, "maxLength": 32, "x-json-name": "Name", "x-go-name": "Name"},
+    "mode": {"type": "string", "enum": ["debug", "release"], "x-go-name": "Mode"},
+    "email": {"type": "string", "format": "email", "x-go-name": "Email"},
+    "endpoint": {"type": "string", "format": "uri", "x-go-name": "Endpoint"},
+    "level": {"type": "integer", "enum": [1, 2, 3], "x-go-name": "Level"},
+    "timeout": {"type": ["string", "integer"], "default": "30s", "x-go-name": "Timeout"},
+    "workers": {"type": "integer", "x-go-name": "Workers"},
+    "tags": {"type": "array", "default": ["a"], "minItems": 1, "items": {"type": "string", "minLength": 2}, "x-go-name": "Tags"},
+    "labels": {"type": "object", "default": {"team": "core"}, "additionalProperties": {"type": "string"}, "x-go-name": "Labels"},
+    "server": {"$ref": "#/$defs/schemaServer", "x-go-name": "Server"},
+    "replicas": {"type": "array", "items": {"$ref": "#/$defs/schemaServer"}, "x-go-name": "Replicas"},
+    "host": {"type": "string", "x-go-name": "Host"}
+  },
+  "additionalProperties": false,
+  "required": ["workers"]
+}`), &expected)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+	}
+
+	var decoded Schema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Properties["timeout"].Type, []string{"string", "integer"}) || *decoded.AdditionalProperties.Bool {
+		t.Errorf("Schema should decode back, but got %+v", decoded)
+	}
+}
@@ -0,0 +1,72 @@
+package configor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultSection holds the values shared by all environments when EnvironmentSections is enabled
+const defaultSection = "default"
+
+// decodeSections decodes the `default` section of file, and then the sections matching
+// the active profiles over it, the same way environment overlay files are decoded
+// over their base file
+func (configor *Configor) decodeSections(config interface{}, file string, data []byte, positions bool) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%v: %v", file, err)
+	}
+
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %v, should have top-level environment sections", file)
+	}
+	root := document.Content[0]
+
+	for _, name := range append([]string{defaultSection}, configor.GetProfiles()...) {
+		section := sectionNode(root, name)
+		if section == nil {
+			continue
+		}
+
+		if configor.Config.Debug || configor.Config.Verbose {
+			fmt.Printf("Loading configurations from section '%v' of file '%v'...\n", name, file)
+		}
+
+		if strings.HasSuffix(file, ".json") {
+			var tree interface{}
+			if err := section.Decode(&tree); err != nil {
+				return err
+			}
+			sectionData, err := json.Marshal(normalizeTree(tree))
+			if err != nil {
+				return err
+			}
+			if err := configor.decodeJSON(config, file, sectionData); err != nil {
+				return err
+			}
+		} else if err := configor.decodeNode(config, file, section); err != nil {
+			return err
+		}
+
+		profile := name
+		if name == defaultSection {
+			profile = ""
+		}
+		configor.recordNodeOrigins(section, nil, reflect.TypeOf(config), nil, file, profile, positions, strings.HasSuffix(file, ".json"))
+	}
+	return nil
+}
+
+// sectionNode returns the value of the top-level section name of root, if any
+func sectionNode(root *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == name {
+			return root.Content[i+1]
+		}
+	}
+	return nil
+}
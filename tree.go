@@ -0,0 +1,217 @@
+package configor
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// normalizeTree converts the `map[interface{}]interface{}` yaml decodes mappings into
+// to `map[string]interface{}`, so the tree can also be encoded as json
+func normalizeTree(tree interface{}) interface{} {
+	switch node := tree.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			result[fmt.Sprint(key)] = normalizeTree(value)
+		}
+		return result
+	case map[string]interface{}:
+		for key, value := range node {
+			node[key] = normalizeTree(value)
+		}
+		return node
+	case []interface{}:
+		for i, value := range node {
+			node[i] = normalizeTree(value)
+		}
+		return node
+	default:
+		return tree
+	}
+}
+
+// mergeTree deep merges src over dst, the same way a file overlay overwrites
+// the values loaded before it: mappings are merged key by key, any other value is replaced
+func mergeTree(dst, src interface{}) interface{} {
+	dstMap, ok := dst.(map[string]interface{})
+	if !ok {
+		return src
+	}
+	srcMap, ok := src.(map[string]interface{})
+	if !ok {
+		return src
+	}
+
+	result := make(map[string]interface{}, len(dstMap)+len(srcMap))
+	for key, value := range dstMap {
+		result[key] = value
+	}
+	for key, value := range srcMap {
+		if dstValue, ok := result[key]; ok {
+			result[key] = mergeTree(dstValue, value)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// GetConfigurationFiles returns the files Load reads for files, in order: directories and glob
+// patterns are expanded, and each file is followed by its overlays for the active profiles,
+// or replaced by its example file when missing
+func (configor *Configor) GetConfigurationFiles(files ...string) []string {
+	return configor.getConfigurationFiles(files...)
+}
+
+// LoadFileTree decodes a single configuration file into a tree of maps and slices, the way Load reads it:
+// includes are resolved and, with EnvironmentSections, the sections of the active profiles are merged
+func (configor *Configor) LoadFileTree(file string) (map[string]interface{}, error) {
+	data, err := readFile(file, configor.UsePkger)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	if hasDirective(normalizeTree(tree), true) {
+		if tree, err = configor.resolveIncludes(file, data, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	root, ok := normalizeTree(tree).(map[string]interface{})
+	if !ok {
+		if tree != nil {
+			return nil, fmt.Errorf("invalid config file %v, should be a mapping", file)
+		}
+		root = map[string]interface{}{}
+	}
+
+	if !configor.EnvironmentSections {
+		configor.recordTreeOrigins(root, file, configor.getFileProfile(file))
+		return root, nil
+	}
+
+	var result interface{} = map[string]interface{}{}
+	for _, name := range append([]string{defaultSection}, configor.GetProfiles()...) {
+		if section, ok := root[name]; ok {
+			if name == defaultSection {
+				configor.recordTreeOrigins(section, file, "")
+			} else {
+				configor.recordTreeOrigins(section, file, name)
+			}
+			result = mergeTree(result, section)
+		}
+	}
+	if result, ok := result.(map[string]interface{}); ok {
+		return result, nil
+	}
+	return nil, fmt.Errorf("invalid config file %v, sections should be mappings", file)
+}
+
+// LoadTree merges the configuration files Load reads for files into a single tree, without binding
+// it to a struct, e.g. to inspect the effective configuration of an environment.
+// Explain reports where each key of the tree was loaded from
+func (configor *Configor) LoadTree(files ...string) (map[string]interface{}, error) {
+	configor.origins = nil
+
+	var result interface{} = map[string]interface{}{}
+	for _, file := range configor.getConfigurationFiles(files...) {
+		tree, err := configor.LoadFileTree(file)
+		if err != nil {
+			return nil, err
+		}
+		result = mergeTree(result, tree)
+	}
+	return result.(map[string]interface{}), nil
+}
+
+// ApplyENV overwrites the leaves of tree with the env vars they are loaded from by Load, named after their
+// key path and the ENV prefix, e.g. `CONFIGOR_DB_HOST` for `db.host`.
+// Load names env vars after struct fields, which only Schema records, e.g. `CONFIGOR_APP_NAME` for the `appname`
+// key of field `APPName`. Without Schema, env var names are approximated from keys, e.g. `CONFIGOR_APPNAME`.
+// Only keys present in tree are looked up, as the names of other keys are only known from the config struct
+func (configor *Configor) ApplyENV(tree map[string]interface{}) {
+	var prefixes []string
+	if prefix := configor.getENVPrefix(tree); prefix != "-" {
+		prefixes = []string{prefix}
+	}
+	configor.applyENV(tree, configor.Schema, nil, prefixes, nil)
+}
+
+// applyENV overwrites the leaves of tree with env vars. schema is the subschema of tree, if any, and envNames
+// the env vars of its field when set by an `env` tag
+func (configor *Configor) applyENV(tree interface{}, schema *Schema, keys []string, prefixes []string, envNames []string) interface{} {
+	if configor.Schema != nil {
+		schema = configor.Schema.resolve(schema)
+	}
+
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			var (
+				name     = key
+				property *Schema
+				names    []string
+			)
+			if schema != nil {
+				if property = treeProperty(schema, key); property != nil {
+					if property.GoName != "" {
+						name = property.GoName
+					}
+					if property.EnvName != "" {
+						names = []string{property.EnvName}
+					}
+				} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Bool == nil {
+					property = schema.AdditionalProperties
+				}
+			}
+			node[key] = configor.applyENV(value, property, append(keys[:len(keys):len(keys)], key), append(prefixes[:len(prefixes):len(prefixes)], name), names)
+		}
+		return node
+	case []interface{}:
+		var items *Schema
+		if schema != nil {
+			items = schema.Items
+		}
+		for i, value := range node {
+			node[i] = configor.applyENV(value, items, append(keys[:len(keys):len(keys)], fmt.Sprint(i)), append(prefixes[:len(prefixes):len(prefixes)], fmt.Sprint(i)), nil)
+		}
+		return node
+	}
+
+	if envNames == nil {
+		envNames = configor.getENVNames(prefixes)
+	}
+	for _, env := range envNames {
+		if value := configor.getenv(env); value != "" {
+			var decoded interface{}
+			if err := yaml.Unmarshal([]byte(value), &decoded); err != nil || decoded == nil {
+				decoded = value
+			}
+			configor.recordOrigin(strings.Join(keys, "."), "env "+env, "")
+			return normalizeTree(decoded)
+		}
+	}
+	return tree
+}
+
+// treeProperty returns the property of schema a key of a tree was loaded from, by its name in yaml or json files,
+// or case-insensitively
+func treeProperty(schema *Schema, key string) *Schema {
+	for name, property := range schema.Properties {
+		if name == key || property.YAMLName == key || property.JSONName == key {
+			return property
+		}
+	}
+	for name, property := range schema.Properties {
+		if strings.EqualFold(name, key) || strings.EqualFold(property.JSONName, key) {
+			return property
+		}
+	}
+	return nil
+}
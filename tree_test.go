@@ -0,0 +1,118 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadTree(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "configor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte("name: app\ndb:\n  host: localhost\n  port: 5432\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "config.production.yml"), []byte("db:\n  host: db.example.org\n"), 0644)
+
+	configor := New(&Config{Environment: "production", Silent: true})
+	files := configor.GetConfigurationFiles(filepath.Join(dir, "config.yml"))
+	if expected := []string{filepath.Join(dir, "config.yml"), filepath.Join(dir, "config.production.yml")}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, files)
+	}
+
+	tree, err := configor.LoadTree(filepath.Join(dir, "config.yml"))
+	if err != nil {
+		t.Fatalf("No error should happen when load tree, but got %v", err)
+	}
+	expected := map[string]interface{}{
+		"name": "app",
+		"db":   map[string]interface{}{"host": "db.example.org", "port": 5432},
+	}
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, tree)
+	}
+
+	for _, origin := range configor.Explain() {
+		if origin.Key == "db.host" && origin.Profile != "production" {
+			t.Errorf("Expected db.host to be loaded from the production overlay, but got %v", origin)
+		}
+	}
+}
+
+func TestApplyENV(t *testing.T) {
+	tree := map[string]interface{}{
+		"name":  "app",
+		"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"hosts": []interface{}{"a", "b"},
+	}
+
+	configor := New(&Config{ENVPrefix: "APP", Env: map[string]string{"APP_DB_PORT": "6543", "APP_HOSTS_1": "c", "APP_UNKNOWN": "x"}})
+	configor.ApplyENV(tree)
+
+	expected := map[string]interface{}{
+		"name":  "app",
+		"db":    map[string]interface{}{"host": "localhost", "port": 6543},
+		"hosts": []interface{}{"a", "c"},
+	}
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, tree)
+	}
+
+	if origins := configor.Explain(); len(origins) != 2 || origins[0].Source != "env APP_DB_PORT" {
+		t.Errorf("Expected env vars to be recorded as origins, but got %v", origins)
+	}
+}
+
+func TestApplyENVSchema(t *testing.T) {
+	type config struct {
+		APPName string
+		MaxSize int
+		DB      struct {
+			Password string `env:"DB_PASSWORD"`
+		}
+		Servers map[string]struct {
+			HostName string
+		}
+	}
+	env := map[string]string{"APP_APP_NAME": "b", "APP_MAXSIZE": "30", "APP_MAX_SIZE": "20", "DB_PASSWORD": "secret", "APP_SERVERS_EU_HOST_NAME": "eu.example.org"}
+	newTree := func() map[string]interface{} {
+		return map[string]interface{}{
+			"appname": "a",
+			"maxsize": 10,
+			"db":      map[string]interface{}{"password": "changeme"},
+			"servers": map[string]interface{}{"eu": map[string]interface{}{"hostname": "localhost"}},
+		}
+	}
+
+	// env vars are named after struct fields, like Load names them
+	tree := newTree()
+	New(&Config{ENVPrefix: "APP", Env: env, Schema: JSONSchema(&config{})}).ApplyENV(tree)
+	expected := map[string]interface{}{
+		"appname": "b",
+		"maxsize": 20,
+		"db":      map[string]interface{}{"password": "secret"},
+		"servers": map[string]interface{}{"eu": map[string]interface{}{"hostname": "eu.example.org"}},
+	}
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, tree)
+	}
+
+	var result config
+	if err := New(&Config{ENVPrefix: "APP", Env: env}).Load(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.APPName != "b" || result.MaxSize != 20 || result.DB.Password != "secret" || result.Servers["eu"].HostName != "eu.example.org" {
+		t.Errorf("Load should read the env vars ApplyENV applies, instead got %+v", result)
+	}
+
+	// without schema, names are approximated from keys
+	tree = newTree()
+	New(&Config{ENVPrefix: "APP", Env: env}).ApplyENV(tree)
+	if tree["appname"] != "a" || tree["maxsize"] != 30 {
+		t.Errorf("Env var names should be made of keys without schema, instead got %+v", tree)
+	}
+}
@@ -0,0 +1,316 @@
+package configor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ByteSize is a size in bytes, decoded from human-friendly values such as `512MiB`, `1.5GB` or `1024`.
+// Binary units (KiB, MiB...) are powers of 1024, decimal units (KB, MB...) are powers of 1000
+type ByteSize uint64
+
+// Byte sizes
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+	PB          = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB          = 1024 * KiB
+	GiB          = 1024 * MiB
+	TiB          = 1024 * GiB
+	PiB          = 1024 * TiB
+)
+
+var byteSizeUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"B", Byte},
+}
+
+// ParseByteSize parses a human-friendly byte size, e.g. `512MiB`
+func ParseByteSize(s string) (ByteSize, error) {
+	number, unit := splitNumberUnit(s)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	if unit == "" {
+		return ByteSize(value), nil
+	}
+	for _, u := range byteSizeUnits {
+		if strings.EqualFold(unit, u.name) || (len(u.name) == 3 && strings.EqualFold(unit, u.name[:1])) {
+			return ByteSize(math.Round(value * float64(u.size))), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size %q, unknown unit %q", s, unit)
+}
+
+// String returns the byte size in the largest unit it is a whole multiple of, e.g. `512MiB`
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteSizeUnits {
+		if b%u.size == 0 {
+			return fmt.Sprintf("%d%v", b/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
+
+// Set implements flag.Value
+func (b *ByteSize) Set(s string) (err error) {
+	*b, err = ParseByteSize(s)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	return b.Set(string(text))
+}
+
+// UnmarshalJSON accepts both json numbers and strings
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	return b.Set(unquoteJSON(data))
+}
+
+// Duration is a time.Duration decoded from values such as `1h30m`, and also accepting days (`7d`) and weeks (`2w`)
+type Duration time.Duration
+
+// Durations longer than time.Hour
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// ParseDuration parses a duration like time.ParseDuration, also accepting `d` and `w` units, e.g. `1w2d12h`,
+// and plain integers as nanoseconds, like json numbers
+func ParseDuration(s string) (Duration, error) {
+	if nanoseconds, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+		return Duration(nanoseconds), nil
+	}
+
+	var (
+		total time.Duration
+		rest  = s
+	)
+	for _, unit := range []struct {
+		name string
+		size time.Duration
+	}{{"w", Week}, {"d", Day}} {
+		if i := strings.Index(rest, unit.name); i > 0 {
+			value, err := strconv.ParseFloat(rest[:i], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			total += time.Duration(value * float64(unit.size))
+			rest = rest[i+1:]
+		}
+	}
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total += d
+	}
+	return Duration(total), nil
+}
+
+// String returns the duration in weeks or days when it is a whole multiple of them, e.g. `7d`,
+// and in time.Duration's format otherwise
+func (d Duration) String() string {
+	switch duration := time.Duration(d); {
+	case duration != 0 && duration%Week == 0:
+		return fmt.Sprintf("%dw", duration/Week)
+	case duration != 0 && duration%Day == 0:
+		return fmt.Sprintf("%dd", duration/Day)
+	default:
+		return duration.String()
+	}
+}
+
+// Set implements flag.Value
+func (d *Duration) Set(s string) (err error) {
+	*d, err = ParseDuration(s)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+// UnmarshalJSON accepts duration strings, and json numbers as nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	return d.Set(unquoteJSON(data))
+}
+
+// Percent is a ratio decoded from values such as `75%`, or `0.75`
+type Percent float64
+
+// ParsePercent parses a percentage, e.g. `75%`, or a ratio, e.g. `0.75`
+func ParsePercent(s string) (Percent, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "%") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percent %q", s)
+		}
+		return Percent(value / 100), nil
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percent %q", s)
+	}
+	return Percent(value), nil
+}
+
+// String returns the percentage, e.g. `75%`
+func (p Percent) String() string {
+	// round off the floating point error of scaling, e.g. 0.07 * 100 is 7.000000000000001
+	return strconv.FormatFloat(math.Round(float64(p)*100*1e9)/1e9, 'f', -1, 64) + "%"
+}
+
+// Set implements flag.Value
+func (p *Percent) Set(s string) (err error) {
+	*p, err = ParsePercent(s)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *Percent) UnmarshalText(text []byte) error {
+	return p.Set(string(text))
+}
+
+// UnmarshalJSON accepts both json numbers and strings
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	return p.Set(unquoteJSON(data))
+}
+
+// Rate is a number of events per period, decoded from values such as `100/s`, `5/m` or `1000/5m`
+type Rate struct {
+	Count  float64
+	Period time.Duration
+}
+
+var rateUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+}
+
+// ParseRate parses a rate, e.g. `100/s`, and a blank string as the zero Rate
+func ParseRate(s string) (Rate, error) {
+	if strings.TrimSpace(s) == "" {
+		return Rate{}, nil
+	}
+
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 2 {
+		return Rate{}, fmt.Errorf("invalid rate %q, should be like 100/s", s)
+	}
+
+	count, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q", s)
+	}
+
+	period, ok := rateUnits[strings.TrimSpace(parts[1])]
+	if !ok {
+		duration, err := ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || duration <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q, unknown period %q", s, parts[1])
+		}
+		period = time.Duration(duration)
+	}
+	return Rate{Count: count, Period: period}, nil
+}
+
+// PerSecond returns the rate as a number of events per second
+func (r Rate) PerSecond() float64 {
+	if r.Period == 0 {
+		return 0
+	}
+	return r.Count / r.Period.Seconds()
+}
+
+// String returns the rate, e.g. `100/s`, and a blank string for the zero Rate
+func (r Rate) String() string {
+	if r == (Rate{}) {
+		return ""
+	}
+	count := strconv.FormatFloat(r.Count, 'f', -1, 64)
+	for _, unit := range []string{"d", "h", "m", "s", "ms"} {
+		if r.Period == rateUnits[unit] {
+			return count + "/" + unit
+		}
+	}
+	return count + "/" + Duration(r.Period).String()
+}
+
+// Set implements flag.Value
+func (r *Rate) Set(s string) (err error) {
+	*r, err = ParseRate(s)
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (r *Rate) UnmarshalText(text []byte) error {
+	return r.Set(string(text))
+}
+
+// splitNumberUnit splits `512MiB` into `512` and `MiB`
+func splitNumberUnit(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// unquoteJSON returns the content of a json string, or data as is for any other json value
+func unquoteJSON(data []byte) string {
+	if s, err := strconv.Unquote(string(data)); err == nil {
+		return s
+	}
+	return string(data)
+}
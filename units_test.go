@@ -0,0 +1,212 @@
+package configor
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseUnits(t *testing.T) {
+	var tests = []struct {
+		value     string
+		parse     func(string) (interface{}, error)
+		expected  interface{}
+		canonical string
+	}{
+		{"512MiB", func(s string) (interface{}, error) { return ParseByteSize(s) }, 512 * MiB, "512MiB"},
+		{"1.5GB", func(s string) (interface{}, error) { return ParseByteSize(s) }, 1500 * MB, "1500MB"},
+		{"2k", func(s string) (interface{}, error) { return ParseByteSize(s) }, 2 * KiB, "2KiB"},
+		{"1500", func(s string) (interface{}, error) { return ParseByteSize(s) }, 1500 * Byte, "1500B"},
+		{"7d", func(s string) (interface{}, error) { return ParseDuration(s) }, Duration(Week), "1w"},
+		{"1d12h", func(s string) (interface{}, error) { return ParseDuration(s) }, Duration(36 * time.Hour), "36h0m0s"},
+		{"90s", func(s string) (interface{}, error) { return ParseDuration(s) }, Duration(90 * time.Second), "1m30s"},
+		{"75%", func(s string) (interface{}, error) { return ParsePercent(s) }, Percent(0.75), "75%"},
+		{"0.5", func(s string) (interface{}, error) { return ParsePercent(s) }, Percent(0.5), "50%"},
+		{"7%", func(s string) (interface{}, error) { return ParsePercent(s) }, Percent(0.07), "7%"},
+		{"29%", func(s string) (interface{}, error) { return ParsePercent(s) }, Percent(0.29), "29%"},
+		{"12.5%", func(s string) (interface{}, error) { return ParsePercent(s) }, Percent(0.125), "12.5%"},
+		{"100/s", func(s string) (interface{}, error) { return ParseRate(s) }, Rate{Count: 100, Period: time.Second}, "100/s"},
+		{"1000/5m", func(s string) (interface{}, error) { return ParseRate(s) }, Rate{Count: 1000, Period: 5 * time.Minute}, "1000/5m0s"},
+	}
+	for _, test := range tests {
+		result, err := test.parse(test.value)
+		if err != nil {
+			t.Errorf("No error should happen when parsing %v, but got %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%v should be parsed as %v, instead got %v", test.value, test.expected, result)
+		}
+		if canonical := result.(interface{ String() string }).String(); canonical != test.canonical {
+			t.Errorf("%v should be formatted as %v, instead got %v", test.value, test.canonical, canonical)
+		}
+	}
+
+	for _, value := range []string{"7%", "29%", "57%", "0.1%", "33.3%"} {
+		percent, _ := ParsePercent(value)
+		text, _ := percent.MarshalText()
+		var result Percent
+		if err := result.UnmarshalText(text); err != nil || result != percent || string(text) != value {
+			t.Errorf("%v should round-trip, instead got %v (%v)", value, string(text), float64(result))
+		}
+	}
+
+	for _, value := range []interface {
+		MarshalText() ([]byte, error)
+	}{ByteSize(0), Duration(0), Percent(0), Rate{}} {
+		text, _ := value.MarshalText()
+		result := reflect.New(reflect.TypeOf(value))
+		if err := result.Interface().(interface{ UnmarshalText([]byte) error }).UnmarshalText(text); err != nil || result.Elem().Interface() != value {
+			t.Errorf("zero %T should round-trip, instead got %q (%v)", value, string(text), err)
+		}
+	}
+
+	for _, value := range []string{"12XB", "-1KiB", "abc"} {
+		if _, err := ParseByteSize(value); err == nil {
+			t.Errorf("Should get error when parsing byte size %v", value)
+		}
+	}
+	for _, value := range []string{"100", "100/fortnight"} {
+		if _, err := ParseRate(value); err == nil {
+			t.Errorf("Should get error when parsing rate %v", value)
+		}
+	}
+}
+
+type unitsConfig struct {
+	MaxSize   ByteSize `default:"512MiB"`
+	Retention Duration `default:"7d"`
+	Threshold Percent  `default:"75%"`
+	Limit     Rate     `default:"100/s"`
+}
+
+func TestLoadUnits(t *testing.T) {
+	expected := unitsConfig{MaxSize: 2 * GiB, Retention: Duration(2 * Week), Threshold: 0.9, Limit: Rate{Count: 5, Period: time.Minute}}
+
+	var defaultsResult unitsConfig
+	if err := New(&Config{ENVPrefix: "UNITS"}).Load(&defaultsResult); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+	if defaults := (unitsConfig{MaxSize: 512 * MiB, Retention: Duration(Week), Threshold: 0.75, Limit: Rate{Count: 100, Period: time.Second}}); !reflect.DeepEqual(defaultsResult, defaults) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", defaults, defaultsResult)
+	}
+
+	for _, ext := range []string{".yaml", ".json"} {
+		file, err := ioutil.TempFile("/tmp", "configor.*"+ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if ext == ".yaml" {
+			file.WriteString("maxsize: 2GiB\nretention: 2w\nthreshold: 90%\nlimit: 5/m\n")
+		} else {
+			file.WriteString(`{"MaxSize": 2147483648, "Retention": "14d", "Threshold": 0.9, "Limit": "5/m"}`)
+		}
+		file.Close()
+
+		var result unitsConfig
+		if err := New(&Config{ENVPrefix: "UNITS"}).Load(&result, file.Name()); err != nil {
+			t.Fatalf("No error should happen when load configurations, but got %v", err)
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", expected, result)
+		}
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("retention: 5\n")
+	file.Close()
+
+	var numberResult unitsConfig
+	if err := New(&Config{ENVPrefix: "UNITS"}).Load(&numberResult, file.Name()); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	} else if numberResult.Retention != 5 {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", Duration(5), numberResult.Retention)
+	}
+
+	os.Setenv("UNITS_MAX_SIZE", "2GiB")
+	os.Setenv("UNITS_RETENTION", "2w")
+	os.Setenv("UNITS_THRESHOLD", "90%")
+	os.Setenv("UNITS_LIMIT", "5/m")
+	defer os.Setenv("UNITS_MAX_SIZE", "")
+	defer os.Setenv("UNITS_RETENTION", "")
+	defer os.Setenv("UNITS_THRESHOLD", "")
+	defer os.Setenv("UNITS_LIMIT", "")
+
+	var envResult unitsConfig
+	if err := New(&Config{ENVPrefix: "UNITS"}).Load(&envResult); err != nil {
+		t.Fatalf("No error should happen when load configurations, but got %v", err)
+	}
+	if !reflect.DeepEqual(envResult, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, envResult)
+	}
+}
+
+func TestMarshalUnits(t *testing.T) {
+	config := unitsConfig{MaxSize: 512 * MiB, Retention: Duration(Week), Threshold: 0.75, Limit: Rate{Count: 100, Period: time.Second}}
+
+	if data, err := json.Marshal(config); err != nil {
+		t.Error(err)
+	} else if expected := `{"MaxSize":"512MiB","Retention":"1w","Threshold":"75%","Limit":"100/s"}`; string(data) != expected {
+		t.Errorf("\nExpected: %v, \nGot: %v", expected, string(data))
+	}
+
+	if data, err := yaml.Marshal(config); err != nil {
+		t.Error(err)
+	} else if expected := "maxsize: 512MiB\nretention: 1w\nthreshold: 75%\nlimit: 100/s\n"; string(data) != expected {
+		t.Errorf("\nExpected: %v, \nGot: %v", expected, string(data))
+	}
+
+	flags := flag.NewFlagSet("units", flag.ContinueOnError)
+	var result unitsConfig
+	flags.Var(&result.MaxSize, "max-size", "")
+	flags.Var(&result.Retention, "retention", "")
+	flags.Var(&result.Threshold, "threshold", "")
+	flags.Var(&result.Limit, "limit", "")
+	if err := flags.Parse([]string{"-max-size=512MiB", "-retention=7d", "-threshold=75%", "-limit=100/s"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, config) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", config, result)
+	}
+}
+
+func TestZeroUnitsExample(t *testing.T) {
+	type zeroUnits struct {
+		MaxSize   ByteSize
+		Retention Duration
+		Threshold Percent
+		Limit     Rate
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		example, err := GenerateExample(&zeroUnits{}, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := ioutil.TempFile("/tmp", "configor.*."+format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.Write(example)
+		file.Close()
+
+		var result zeroUnits
+		if err := New(&Config{}).Load(&result, file.Name()); err != nil {
+			t.Errorf("No error should happen when load the %v example, but got %v\n%s", format, err, example)
+		} else if !reflect.DeepEqual(result, zeroUnits{}) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", zeroUnits{}, result)
+		}
+	}
+}
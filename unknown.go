@@ -0,0 +1,144 @@
+package configor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reservedENVs configure configor itself, and share its default ENV prefix
+var reservedENVs = []string{
+	"CONFIGOR_ENV", "CONFIGOR_ENV_PREFIX", "CONFIGOR_ENV_SEPARATOR", "CONFIGOR_PROFILES",
+	"CONFIGOR_DEBUG_MODE", "CONFIGOR_VERBOSE_MODE", "CONFIGOR_SILENT_MODE",
+	"CONFIGOR_USE_PKGER", "CONFIGOR_ENVIRONMENT_SECTIONS", "CONFIGOR_UPDATE_EXAMPLES",
+}
+
+// addKnownENVs records env var names a field may be loaded from
+func (configor *Configor) addKnownENVs(envNames ...string) {
+	if configor.knownENVs == nil {
+		configor.knownENVs = map[string]bool{}
+	}
+	for _, envName := range envNames {
+		configor.knownENVs[envName] = true
+	}
+}
+
+// addKnownENVPrefixes records env var name prefixes of the entries of a map or slice of primitives.
+// Struct entries are checked against the env var names of their fields instead
+func (configor *Configor) addKnownENVPrefixes(indexed bool, envNames ...string) {
+	if configor.knownENVPrefixes == nil {
+		configor.knownENVPrefixes = map[string]bool{}
+	}
+	for _, envName := range envNames {
+		configor.knownENVPrefixes[envName+configor.getENVSeparator()] = indexed
+	}
+}
+
+func (configor *Configor) isKnownENV(env string) bool {
+	if configor.knownENVs[env] {
+		return true
+	}
+	for prefix, indexed := range configor.knownENVPrefixes {
+		if strings.HasPrefix(env, prefix) && (!indexed || isIndex(env[len(prefix):])) {
+			return true
+		}
+	}
+	for _, reserved := range reservedENVs {
+		if env == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// isIndex reports whether s is a slice index, e.g. the `0` of `CONFIGOR_HOSTS_0`
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnknownENVs reports env vars starting with the ENV prefix that match no field,
+// as warnings, or as an error when ErrorOnUnknownENVs is set
+func (configor *Configor) checkUnknownENVs(prefix string) error {
+	if !configor.WarnOnUnknownENVs && !configor.ErrorOnUnknownENVs || prefix == "-" {
+		return nil
+	}
+
+	var messages []string
+	for _, envPrefix := range configor.getENVNames([]string{prefix}) {
+		for name := range configor.lookupENVWithPrefix(envPrefix + configor.getENVSeparator()) {
+			env := envPrefix + configor.getENVSeparator() + name
+			if configor.isKnownENV(env) {
+				continue
+			}
+
+			message := fmt.Sprintf("unknown env %v", env)
+			if suggestion := configor.suggestENV(env); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %v?", suggestion)
+			}
+			messages = append(messages, message)
+		}
+	}
+	sort.Strings(messages)
+
+	if len(messages) == 0 {
+		return nil
+	}
+	if configor.ErrorOnUnknownENVs {
+		return errors.New(strings.Join(messages, "; "))
+	}
+	if !configor.Silent {
+		for _, message := range messages {
+			fmt.Printf("Warning: %v\n", message)
+		}
+	}
+	return nil
+}
+
+// suggestENV returns the known env var name closest to env, if it is close enough to be a typo
+func (configor *Configor) suggestENV(env string) string {
+	var (
+		suggestion string
+		best       = len(env)/3 + 1
+	)
+	for known := range configor.knownENVs {
+		if distance := levenshtein(env, known); distance < best || (distance == best && known < suggestion) {
+			suggestion, best = known, distance
+		}
+	}
+	return suggestion
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,72 @@
+package configor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnknownENVs(t *testing.T) {
+	type config struct {
+		DB struct {
+			Host string
+			Port uint
+		}
+		Labels   map[string]string
+		Hosts    []string
+		Contacts []struct {
+			Name  string
+			Email string
+		}
+		Servers map[string]struct {
+			Host string
+		}
+	}
+
+	os.Setenv("UNKNOWN_DB_PROT", "5432")
+	os.Setenv("UNKNOWN_LABELS_TEAM", "core")
+	os.Setenv("UNKNOWN_HOSTS_0", "http://example.org")
+	defer os.Setenv("UNKNOWN_DB_PROT", "")
+	defer os.Setenv("UNKNOWN_LABELS_TEAM", "")
+	defer os.Setenv("UNKNOWN_HOSTS_0", "")
+	os.Setenv("UNKNOWN_CONTACTS_0_NAME", "jinzhu")
+	defer os.Setenv("UNKNOWN_CONTACTS_0_NAME", "")
+	os.Setenv("UNKNOWN_SERVERS_MAIN_HOST", "localhost")
+	defer os.Setenv("UNKNOWN_SERVERS_MAIN_HOST", "")
+
+	var result config
+	if err := New(&Config{ENVPrefix: "UNKNOWN", Silent: true}).Load(&result); err != nil {
+		t.Errorf("Should NOT get error for unknown env vars when the check is disabled. Error: %v", err)
+	}
+
+	if err := New(&Config{ENVPrefix: "UNKNOWN", WarnOnUnknownENVs: true, Silent: true}).Load(&result); err != nil {
+		t.Errorf("Should NOT get error for unknown env vars when only warning. Error: %v", err)
+	}
+
+	err := New(&Config{ENVPrefix: "UNKNOWN", ErrorOnUnknownENVs: true}).Load(&result)
+	if err == nil {
+		t.Fatal("Should get error for unknown env vars in strict mode")
+	}
+	if expected := "unknown env UNKNOWN_DB_PROT, did you mean UNKNOWN_DB_PORT?"; err.Error() != expected {
+		t.Errorf("Error should be %v, instead got %v", expected, err)
+	}
+	if strings.Contains(err.Error(), "LABELS") || strings.Contains(err.Error(), "HOSTS") || strings.Contains(err.Error(), "CONTACTS") || strings.Contains(err.Error(), "SERVERS") {
+		t.Errorf("Map entries and slice elements should not be reported as unknown env vars")
+	}
+
+	// fields of struct entries, and indexes of primitive slices, are checked too
+	for name, expected := range map[string]string{
+		"UNKNOWN_CONTACTS_0_NAEM":   "unknown env UNKNOWN_CONTACTS_0_NAEM, did you mean UNKNOWN_CONTACTS_0_NAME?",
+		"UNKNOWN_SERVERS_MAIN_HSOT": "unknown env UNKNOWN_SERVERS_MAIN_HSOT, did you mean UNKNOWN_SERVERS_MAIN_HOST?",
+		"UNKNOWN_HOSTS_FIRST":       "unknown env UNKNOWN_HOSTS_FIRST",
+	} {
+		os.Setenv(name, "typo")
+		result = config{}
+		err := New(&Config{ENVPrefix: "UNKNOWN", ErrorOnUnknownENVs: true}).Load(&result)
+		os.Setenv(name, "")
+		if !strings.Contains(fmt.Sprint(err), expected) {
+			t.Errorf("Error should contain %v, instead got %v", expected, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package configor
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,21 +9,20 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/creasty/defaults"
 	"github.com/go-playground/validator/v10"
 	"github.com/markbates/pkger"
-	"github.com/markbates/pkger/pkging"
 	// "github.com/kelseyhightower/envconfig"
-	"github.com/stoewer/go-strcase"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 func (configor *Configor) getENVPrefix(config interface{}) string {
 	if configor.Config.ENVPrefix == "" {
-		if prefix := os.Getenv("CONFIGOR_ENV_PREFIX"); prefix != "" {
+		if prefix := configor.getenv("CONFIGOR_ENV_PREFIX"); prefix != "" {
 			return prefix
 		}
 		return "Configor"
@@ -48,14 +48,87 @@ func getConfigurationFileWithENVPrefix(file, env string, usePkger bool) (string,
 	return "", fmt.Errorf("failed to find file %v", file)
 }
 
+// expandConfigurationFiles expands directories and glob patterns into the
+// configuration files they contain, in lexical order.
+// Environment and example overlays, e.g. `db.production.yaml`, are left out, as they
+// are resolved per file by getConfigurationFiles, only when their base file exists
+func (configor *Configor) expandConfigurationFiles(file string) []string {
+	var matches []string
+
+	if strings.ContainsAny(file, "*?[") {
+		matches, _ = glob(file, configor.UsePkger)
+	} else if fileInfo, err := stat(file, configor.UsePkger); err == nil && fileInfo.IsDir() {
+		names, _ := readDir(file, configor.UsePkger)
+		for _, name := range names {
+			if isConfigurationFile(name) {
+				matches = append(matches, path.Join(file, name))
+			}
+		}
+	} else {
+		return []string{file}
+	}
+
+	var result []string
+	for _, match := range matches {
+		if fileInfo, err := stat(match, configor.UsePkger); err != nil || !fileInfo.Mode().IsRegular() || configor.isOverlayFile(match) {
+			continue
+		}
+		result = append(result, match)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isOverlayFile reports whether file is an environment or example overlay, e.g. `db.production.yaml`
+func (configor *Configor) isOverlayFile(file string) bool {
+	envExtname := path.Ext(strings.TrimSuffix(file, path.Ext(file)))
+	if envExtname == "" {
+		return false
+	}
+	return configor.isKnownProfile(strings.TrimPrefix(envExtname, "."))
+}
+
+// isKnownProfile reports whether name is `example`, a built-in, known, aliased or active environment
+func (configor *Configor) isKnownProfile(name string) bool {
+	switch name {
+	case "example", "development", "test", "production":
+		return true
+	}
+	if _, ok := configor.EnvironmentAliases[name]; ok {
+		return true
+	}
+	for _, profile := range append(configor.Environments, configor.GetProfiles()...) {
+		if profile == name {
+			return true
+		}
+	}
+	for _, env := range configor.EnvironmentAliases {
+		if env == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (configor *Configor) getConfigurationFiles(files ...string) []string {
-	var resultKeys []string
+	var (
+		resultKeys    []string
+		expandedFiles []string
+	)
 
 	if configor.Config.Debug || configor.Config.Verbose {
-		fmt.Printf("Current environment: '%v'\n", configor.GetEnvironment())
+		fmt.Printf("Current environment: '%v', profiles: %v\n", configor.GetEnvironment(), configor.GetProfiles())
 	}
 
 	for _, file := range files {
+		expanded := configor.expandConfigurationFiles(file)
+		if len(expanded) == 0 && !configor.Silent {
+			fmt.Printf("Failed to find configuration %v\n", file)
+		}
+		expandedFiles = append(expandedFiles, expanded...)
+	}
+
+	for _, file := range expandedFiles {
 		foundFile := false
 
 		// check configuration
@@ -64,10 +137,12 @@ func (configor *Configor) getConfigurationFiles(files ...string) []string {
 			resultKeys = append(resultKeys, file)
 		}
 
-		// check configuration with env
-		if file, err := getConfigurationFileWithENVPrefix(file, configor.GetEnvironment(), configor.UsePkger); err == nil {
-			foundFile = true
-			resultKeys = append(resultKeys, file)
+		// check configuration with env, for each profile in order
+		for _, profile := range configor.GetProfiles() {
+			if file, err := getConfigurationFileWithENVPrefix(file, profile, configor.UsePkger); err == nil {
+				foundFile = true
+				resultKeys = append(resultKeys, file)
+			}
 		}
 
 		// check example configuration
@@ -85,56 +160,155 @@ func (configor *Configor) getConfigurationFiles(files ...string) []string {
 	return resultKeys
 }
 
-func (configor *Configor) processFile(config interface{}, file string) (err error) {
+// processFile loads a configuration file into config, validating it against schema first if not nil
+func (configor *Configor) processFile(config interface{}, file string, schema *Schema) (err error) {
 	var data []byte
-	if configor.UsePkger {
-		var fh pkging.File
-		if fh, err = pkger.Open(file); err != nil {
+	if data, err = readFile(file, configor.UsePkger); err != nil {
+		return err
+	}
+
+	if schema != nil {
+		if err = configor.validateFile(file, data, schema); err != nil {
 			return err
 		}
-		defer fh.Close()
-		if data, err = ioutil.ReadAll(fh); err != nil {
+	}
+
+	processed := data
+	if hasIncludes(data) {
+		if processed, err = configor.processIncludes(file, data); err != nil {
 			return err
 		}
-	} else {
-		if data, err = ioutil.ReadFile(file); err != nil {
-			return err
+	}
+	// positions in re-encoded content don't match the file
+	positions := bytes.Equal(processed, data)
+
+	if configor.EnvironmentSections {
+		if err = configor.decodeSections(config, file, processed, positions); err != nil && !positions {
+			return withoutPositions(err)
 		}
+		return err
+	}
+	if err = configor.decode(config, file, processed); err != nil {
+		if !positions {
+			return withoutPositions(err)
+		}
+		return err
 	}
+	configor.recordOrigins(config, processed, file, configor.getFileProfile(file), positions)
+	return nil
+}
 
+// isJSONFile reports whether decode decodes data of file as json: by the file extension, or for other
+// extensions when data is valid json
+func isJSONFile(file string, data []byte) bool {
 	switch {
 	case strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml"):
-		if configor.GetErrorOnUnmatchedKeys() {
-			return yaml.UnmarshalStrict(data, config)
-		}
-		return yaml.Unmarshal(data, config)
+		return false
 	case strings.HasSuffix(file, ".json"):
-		return unmarshalJSON(data, config, configor.GetErrorOnUnmatchedKeys())
+		return true
+	}
+	return json.Valid(data)
+}
+
+// decode unmarshals data into config, guessing its format from the file extension
+func (configor *Configor) decode(config interface{}, file string, data []byte) error {
+	switch {
+	case strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml"):
+		return configor.decodeYAML(config, file, data)
+	case strings.HasSuffix(file, ".json"):
+		return configor.decodeJSON(config, file, data)
 	default:
 
-		if err := unmarshalJSON(data, config, configor.GetErrorOnUnmatchedKeys()); err == nil {
+		if err := configor.decodeJSON(config, file, data); err == nil {
 			return nil
-		} else if strings.Contains(err.Error(), "json: unknown field") {
+		} else if _, ok := err.(*FileErrors); ok || strings.Contains(err.Error(), "json: unknown field") {
 			return err
 		}
 
-		var yamlError error
-		if configor.GetErrorOnUnmatchedKeys() {
-			yamlError = yaml.UnmarshalStrict(data, config)
-		} else {
-			yamlError = yaml.Unmarshal(data, config)
-		}
-
-		if yamlError == nil {
+		if yamlError := configor.decodeYAML(config, file, data); yamlError == nil {
 			return nil
-		} else if yErr, ok := yamlError.(*yaml.TypeError); ok {
-			return yErr
+		} else if _, ok := yamlError.(*FileErrors); ok {
+			return yamlError
 		}
 
 		return errors.New("failed to decode config")
 	}
 }
 
+// decodeYAML decodes yaml data of file into config.
+// Type errors, and unknown keys with ErrorOnUnmatchedKeys, are returned as FileErrors
+func (configor *Configor) decodeYAML(config interface{}, file string, data []byte) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%v: %v", file, err)
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	return configor.decodeNode(config, file, &document)
+}
+
+// decodeNode decodes a yaml node parsed from file into config
+func (configor *Configor) decodeNode(config interface{}, file string, node *yaml.Node) error {
+	hooked, hookErrs := hookedScalars{}, &FileErrors{}
+	if configor.runNodeDecodeHooks(file, node, reflect.TypeOf(config), nil, false, hooked, hookErrs); len(hookErrs.Errors) > 0 {
+		return hookErrs
+	}
+
+	decoded := node
+	var err error
+	if configor.GetErrorOnUnmatchedKeys() {
+		// Node.Decode doesn't reject unknown keys, so decode the node re-encoded instead, and locate
+		// errors by their keys in node
+		var data []byte
+		if data, err = yaml.Marshal(node); err != nil {
+			return fmt.Errorf("%v: %v", file, err)
+		}
+		decoded = &yaml.Node{}
+		if err = yaml.Unmarshal(data, decoded); err != nil {
+			return fmt.Errorf("%v: %v", file, err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(config); err == io.EOF {
+			err = nil
+		}
+	} else {
+		err = node.Decode(config)
+	}
+
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		return locateTypeErrors(file, node, decoded, typeErr)
+	} else if err != nil {
+		return fmt.Errorf("%v: %v", file, err)
+	}
+	return setHookedScalars(node, reflect.ValueOf(config), false, hooked)
+}
+
+// decodeJSON decodes json data of file into config. json is yaml, so decode hooks run on the scalars
+// of its yaml node like for yaml files, and the node is decoded re-encoded when they decoded any
+func (configor *Configor) decodeJSON(config interface{}, file string, data []byte) error {
+	var document yaml.Node
+	if !json.Valid(data) || yaml.Unmarshal(data, &document) != nil || len(document.Content) == 0 {
+		return unmarshalJSON(data, config, configor.GetErrorOnUnmatchedKeys())
+	}
+
+	hooked, hookErrs := hookedScalars{}, &FileErrors{}
+	if configor.runNodeDecodeHooks(file, &document, reflect.TypeOf(config), nil, true, hooked, hookErrs); len(hookErrs.Errors) > 0 {
+		return hookErrs
+	}
+	if len(hooked) == 0 {
+		return unmarshalJSON(data, config, configor.GetErrorOnUnmatchedKeys())
+	}
+
+	var buf bytes.Buffer
+	writeJSONNode(&buf, document.Content[0], "")
+	if err := unmarshalJSON(buf.Bytes(), config, configor.GetErrorOnUnmatchedKeys()); err != nil {
+		return err
+	}
+	return setHookedScalars(&document, reflect.ValueOf(config), true, hooked)
+}
+
 // unmarshalJSON unmarshals the given data into the config interface.
 // If the errorOnUnmatchedKeys boolean is true, an error will be returned if there
 // are keys in the data that do not match fields in the config interface.
@@ -160,7 +334,18 @@ func getPrefixForStruct(prefixes []string, fieldStruct *reflect.StructField) []s
 	return append(prefixes, fieldStruct.Name)
 }
 
-func (configor *Configor) processTags(config interface{}, prefixes ...string) error {
+// getKeysForField returns the key path of a struct field in configuration files, the fields of
+// yaml inline structs have the key path of the struct they are inlined in
+func getKeysForField(keys []string, fieldStruct *reflect.StructField) []string {
+	if strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") {
+		return keys[:len(keys):len(keys)]
+	}
+	return append(keys[:len(keys):len(keys)], getFieldKey(fieldStruct))
+}
+
+// processTags loads config fields from shell env. root is the top-level config, and keys the key path
+// of config in configuration files
+func (configor *Configor) processTags(root, config interface{}, keys []string, prefixes ...string) error {
 	configValue := reflect.Indirect(reflect.ValueOf(config))
 	if configValue.Kind() != reflect.Struct {
 		return errors.New("invalid config, should be struct")
@@ -180,45 +365,38 @@ func (configor *Configor) processTags(config interface{}, prefixes ...string) er
 		}
 
 		if envName == "" {
-			envNames = append(envNames, strcase.UpperSnakeCase(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
-			// envNames = append(envNames, strings.Join(append(prefixes, fieldStruct.Name), "_"))                  // Configor_DB_Name
-			// envNames = append(envNames, strings.ToUpper(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
+			envNames = configor.getENVNames(append(prefixes[:len(prefixes):len(prefixes)], fieldStruct.Name)) // CONFIGOR_DB_NAME
 		} else {
 			envNames = []string{envName}
 		}
 
+		configor.addKnownENVs(envNames...)
+
 		if configor.Config.Verbose {
 			fmt.Printf("Trying to load struct `%v`'s field `%v` from env %v\n", configType.Name(), fieldStruct.Name, strings.Join(envNames, ", "))
 		}
 
 		// Load From Shell ENV
 		for _, env := range envNames {
-			if value := os.Getenv(env); value != "" {
+			if value := configor.getenv(env); value != "" {
 				if configor.Config.Debug || configor.Config.Verbose {
 					fmt.Printf("Loading configuration for struct `%v`'s field `%v` from env %v...\n", configType.Name(), fieldStruct.Name, env)
 				}
 
-				switch reflect.Indirect(field).Kind() {
-				case reflect.Bool:
-					switch strings.ToLower(value) {
-					case "", "0", "f", "false":
-						field.Set(reflect.ValueOf(false))
-					default:
-						field.Set(reflect.ValueOf(true))
-					}
-				case reflect.String:
-					field.Set(reflect.ValueOf(value))
-				default:
-					if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+				if sep := fieldStruct.Tag.Get("sep"); sep != "" && field.Kind() == reflect.Slice {
+					if err := configor.setSliceValue(field, value, sep); err != nil {
 						return err
 					}
+				} else if err := configor.setValue(field, value); err != nil {
+					return err
 				}
+				configor.recordOrigin(strings.Join(getKeysForField(keys, &fieldStruct), "."), "env "+env, "")
 				break
 			}
 		}
 
-		if isBlank := reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()); isBlank && fieldStruct.Tag.Get("required") == "true" {
-			// return error if it is required but blank
+		if fieldStruct.Tag.Get("required") == "true" && !configor.isRequiredSet(field, strings.Join(getKeysForField(keys, &fieldStruct), ".")) {
+			// return error if it is required but not set by any source
 			return errors.New(fieldStruct.Name + " is required, but blank")
 		}
 
@@ -227,7 +405,23 @@ func (configor *Configor) processTags(config interface{}, prefixes ...string) er
 		}
 
 		if field.Kind() == reflect.Struct {
-			if err := configor.processTags(field.Addr().Interface(), getPrefixForStruct(prefixes, &fieldStruct)...); err != nil {
+			if err := configor.processTags(root, field.Addr().Interface(), getKeysForField(keys, &fieldStruct), getPrefixForStruct(prefixes, &fieldStruct)...); err != nil {
+				return err
+			}
+		}
+
+		if (field.Kind() == reflect.Map || field.Kind() == reflect.Slice) && indirectType(field.Type().Elem()).Kind() != reflect.Struct {
+			configor.addKnownENVPrefixes(field.Kind() == reflect.Slice, envNames...)
+		}
+
+		if field.Kind() == reflect.Map && field.Type().Key().Kind() == reflect.String {
+			if err := configor.processMap(root, field, envNames, getKeysForField(keys, &fieldStruct), getPrefixForStruct(prefixes, &fieldStruct)); err != nil {
+				return err
+			}
+		}
+
+		if field.Kind() == reflect.Slice && indirectType(field.Type().Elem()).Kind() != reflect.Struct {
+			if err := configor.processSlice(field, envNames, getKeysForField(keys, &fieldStruct)); err != nil {
 				return err
 			}
 		}
@@ -236,7 +430,7 @@ func (configor *Configor) processTags(config interface{}, prefixes ...string) er
 			if arrLen := field.Len(); arrLen > 0 {
 				for i := 0; i < arrLen; i++ {
 					if reflect.Indirect(field.Index(i)).Kind() == reflect.Struct {
-						if err := configor.processTags(field.Index(i).Addr().Interface(), append(getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(i))...); err != nil {
+						if err := configor.processTags(root, field.Index(i).Addr().Interface(), append(getKeysForField(keys, &fieldStruct), fmt.Sprint(i)), append(getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(i))...); err != nil {
 							return err
 						}
 					}
@@ -248,7 +442,7 @@ func (configor *Configor) processTags(config interface{}, prefixes ...string) er
 					idx := 0
 					for {
 						newVal = reflect.New(field.Type().Elem()).Elem()
-						if err := configor.processTags(newVal.Addr().Interface(), append(getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(idx))...); err != nil {
+						if err := configor.processTags(root, newVal.Addr().Interface(), append(getKeysForField(keys, &fieldStruct), fmt.Sprint(idx)), append(getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(idx))...); err != nil {
 							return err
 						} else if reflect.DeepEqual(newVal.Interface(), reflect.New(field.Type().Elem()).Elem().Interface()) {
 							break
@@ -273,15 +467,33 @@ func (configor *Configor) load(config interface{}, files ...string) (err error)
 
 			fmt.Printf("Configuration:\n  %#v\n", config)
 		}
+
+		if configor.Config.Verbose {
+			fmt.Println("Configuration sources:")
+			for _, origin := range configor.Explain() {
+				fmt.Printf("  %v\n", origin)
+			}
+		}
 	}()
 
+	configor.origins = nil
 	configFiles := configor.getConfigurationFiles(files...)
+	schema := configor.getSchema(config)
 
 	for _, file := range configFiles {
 		if configor.Config.Debug || configor.Config.Verbose {
 			fmt.Printf("Loading configurations from file '%v'...\n", file)
 		}
-		if err = configor.processFile(config, file); err != nil {
+		if err = configor.processFile(config, file, schema); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range configor.ConfigMaps {
+		if configor.Config.Debug || configor.Config.Verbose {
+			fmt.Printf("Loading configurations from ConfigMap '%v'...\n", dir)
+		}
+		if err = configor.processConfigMap(config, dir); err != nil {
 			return err
 		}
 	}
@@ -291,7 +503,7 @@ func (configor *Configor) load(config interface{}, files ...string) (err error)
 	}
 
 	// process defaults
-	if err = defaults.Set(config); err != nil {
+	if err = configor.setDefaults(config, config, nil); err != nil {
 		return err
 	}
 
@@ -299,10 +511,16 @@ func (configor *Configor) load(config interface{}, files ...string) (err error)
 		fmt.Printf("Configuration after loading files and setting Defaults, before processing ENV:\n  %#v\n", config)
 	}
 
-	if prefix := configor.getENVPrefix(config); prefix == "-" {
-		err = configor.processTags(config)
+	configor.knownENVs, configor.knownENVPrefixes = nil, nil
+	prefix := configor.getENVPrefix(config)
+	if prefix == "-" {
+		err = configor.processTags(config, config, nil)
 	} else {
-		err = configor.processTags(config, prefix)
+		err = configor.processTags(config, config, nil, prefix)
+	}
+
+	if err == nil {
+		err = configor.checkUnknownENVs(prefix)
 	}
 
 	// validate config only if no parsing errors
@@ -322,3 +540,63 @@ func stat(name string, usePkger bool) (os.FileInfo, error) {
 		return os.Stat(name)
 	}
 }
+
+func readFile(name string, usePkger bool) ([]byte, error) {
+	if usePkger {
+		fh, err := pkger.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer fh.Close()
+		return ioutil.ReadAll(fh)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func readDir(name string, usePkger bool) ([]string, error) {
+	var fileInfos []os.FileInfo
+	if usePkger {
+		fh, err := pkger.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer fh.Close()
+		if fileInfos, err = fh.Readdir(-1); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if fileInfos, err = ioutil.ReadDir(name); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		names = append(names, fileInfo.Name())
+	}
+	return names, nil
+}
+
+func glob(pattern string, usePkger bool) ([]string, error) {
+	if !usePkger {
+		return filepath.Glob(pattern)
+	}
+
+	// pkger has no glob support, so only the last element of pattern may contain wildcards
+	dir, filePattern := path.Split(pattern)
+	names, err := readDir(dir, usePkger)
+	if err != nil {
+		return nil, nil
+	}
+
+	var matches []string
+	for _, name := range names {
+		if matched, err := path.Match(filePattern, name); err != nil {
+			return nil, err
+		} else if matched {
+			matches = append(matches, path.Join(dir, name))
+		}
+	}
+	return matches, nil
+}
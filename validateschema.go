@@ -0,0 +1,357 @@
+package configor
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError is a value of a configuration file that doesn't match the JSON Schema
+type SchemaError struct {
+	File string
+	// Position of the value in File, zero when unknown, e.g. for files with includes
+	Line   int
+	Column int
+	// JSON pointer of the value, e.g. `/db/port`
+	Pointer string
+	Message string
+}
+
+// Error returns the error as `config.yml:14:3: /db/port: expected integer, got string`
+func (err SchemaError) Error() string {
+	pointer := err.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	if err.Line > 0 {
+		return fmt.Sprintf("%v:%d:%d: %v: %v", err.File, err.Line, err.Column, pointer, err.Message)
+	}
+	return fmt.Sprintf("%v: %v: %v", err.File, pointer, err.Message)
+}
+
+// SchemaErrors are all the values of configuration files that don't match the JSON Schema
+type SchemaErrors []SchemaError
+
+func (errs SchemaErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// getSchema returns the schema configuration files of config are validated against, if any
+func (configor *Configor) getSchema(config interface{}) *Schema {
+	if configor.Schema != nil {
+		return configor.Schema
+	}
+	if configor.ValidateSchema {
+		return JSONSchema(config)
+	}
+	return nil
+}
+
+// ValidateFile validates a configuration file against schema, the way Load reads it: with includes
+// resolved and, with EnvironmentSections, the sections of the active profiles.
+// `required` keywords are not checked, as keys may be set by other files or env vars.
+// The returned error is SchemaErrors when the file doesn't match the schema
+func (configor *Configor) ValidateFile(file string, schema *Schema) error {
+	data, err := readFile(file, configor.UsePkger)
+	if err != nil {
+		return err
+	}
+	return configor.validateFile(file, data, schema)
+}
+
+func (configor *Configor) validateFile(file string, data []byte, schema *Schema) error {
+	processed := data
+	if hasIncludes(data) {
+		var err error
+		if processed, err = configor.processIncludes(file, data); err != nil {
+			return err
+		}
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(processed, &document); err != nil {
+		return fmt.Errorf("%v: %v", file, err)
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	validator := schemaValidator{root: schema, file: file, positions: bytes.Equal(processed, data), jsonFile: isJSONFile(file, processed)}
+	node := document.Content[0]
+	if configor.EnvironmentSections && node.Kind == yaml.MappingNode {
+		for _, name := range append([]string{defaultSection}, configor.GetProfiles()...) {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == name {
+					validator.validate(schema, node.Content[i+1], "/"+escapePointer(name))
+				}
+			}
+		}
+	} else {
+		validator.validate(schema, node, "")
+	}
+
+	if len(validator.errs) > 0 {
+		return validator.errs
+	}
+	return nil
+}
+
+// schemaValidator validates yaml nodes against a schema, collecting all errors
+type schemaValidator struct {
+	root      *Schema
+	file      string
+	positions bool
+	// keys of json files are matched like encoding/json does, by json names and case-insensitively
+	jsonFile bool
+	errs     SchemaErrors
+}
+
+func (validator *schemaValidator) fail(node *yaml.Node, pointer string, format string, args ...interface{}) {
+	err := SchemaError{File: validator.file, Pointer: pointer, Message: fmt.Sprintf(format, args...)}
+	if validator.positions {
+		err.Line, err.Column = node.Line, node.Column
+	}
+	validator.errs = append(validator.errs, err)
+}
+
+// resolve follows `$ref`s to the `$defs` of the root schema
+func (validator *schemaValidator) resolve(schema *Schema) *Schema {
+	return validator.root.resolve(schema)
+}
+
+// resolve follows the `$ref`s of schema, a subschema of root, to the `$defs` of root.
+// It returns nil for references to other documents
+func (root *Schema) resolve(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		name := strings.TrimPrefix(schema.Ref, "#/$defs/")
+		if name == schema.Ref || root.Defs[name] == nil {
+			// only local definitions are supported, accept anything else
+			return nil
+		}
+		schema = root.Defs[name]
+	}
+	return schema
+}
+
+func (validator *schemaValidator) validate(schema *Schema, node *yaml.Node, pointer string) {
+	if schema = validator.resolve(schema); schema == nil {
+		return
+	}
+	if schema.Bool != nil {
+		if !*schema.Bool {
+			validator.fail(node, pointer, "not allowed")
+		}
+		return
+	}
+
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		// blank values leave fields unchanged
+		return
+	}
+
+	nodeType := yamlNodeType(node)
+	if len(schema.Type) > 0 && !schemaAccepts(schema, nodeType) {
+		validator.fail(node, pointer, "expected %v, got %v", strings.Join(schema.Type, " or "), nodeType)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		validator.validateMapping(schema, node, pointer)
+	case yaml.SequenceNode:
+		if schema.MinItems != nil && len(node.Content) < *schema.MinItems {
+			validator.fail(node, pointer, "should have at least %d items, got %d", *schema.MinItems, len(node.Content))
+		}
+		if schema.MaxItems != nil && len(node.Content) > *schema.MaxItems {
+			validator.fail(node, pointer, "should have at most %d items, got %d", *schema.MaxItems, len(node.Content))
+		}
+		if schema.Items != nil {
+			for i, item := range node.Content {
+				validator.validate(schema.Items, item, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	case yaml.ScalarNode:
+		validator.validateScalar(schema, node, nodeType, pointer)
+	}
+}
+
+func (validator *schemaValidator) validateMapping(schema *Schema, node *yaml.Node, pointer string) {
+	if count := len(node.Content) / 2; schema.MinProperties != nil && count < *schema.MinProperties {
+		validator.fail(node, pointer, "should have at least %d keys, got %d", *schema.MinProperties, count)
+	} else if schema.MaxProperties != nil && count > *schema.MaxProperties {
+		validator.fail(node, pointer, "should have at most %d keys, got %d", *schema.MaxProperties, count)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var (
+			key      = node.Content[i]
+			value    = node.Content[i+1]
+			property = validator.property(schema, key.Value)
+			keyPath  = pointer + "/" + escapePointer(key.Value)
+		)
+		if key.Tag == "!!merge" {
+			continue
+		}
+
+		switch {
+		case property != nil:
+			validator.validate(property, value, keyPath)
+		case schema.AdditionalProperties != nil && schema.AdditionalProperties.Bool != nil && !*schema.AdditionalProperties.Bool:
+			validator.fail(key, keyPath, "unknown key")
+		case schema.AdditionalProperties != nil:
+			validator.validate(schema.AdditionalProperties, value, keyPath)
+		}
+	}
+}
+
+var (
+	hostnameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,62}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,62}[a-zA-Z0-9])?)*\.?$`)
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+func (validator *schemaValidator) validateScalar(schema *Schema, node *yaml.Node, nodeType, pointer string) {
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, option := range schema.Enum {
+			found = found || node.Value == strings.TrimSuffix(fmt.Sprint(option), ".0")
+			if number, ok := option.(float64); ok {
+				value, err := strconv.ParseFloat(node.Value, 64)
+				found = found || (err == nil && value == number)
+			}
+		}
+		if !found {
+			validator.fail(node, pointer, "should be one of %v, got %v", schema.Enum, node.Value)
+		}
+	}
+
+	if nodeType == "integer" || nodeType == "number" {
+		if value, err := strconv.ParseFloat(strings.ReplaceAll(node.Value, "_", ""), 64); err == nil {
+			switch {
+			case schema.Minimum != nil && value < *schema.Minimum:
+				validator.fail(node, pointer, "should be at least %v, got %v", *schema.Minimum, node.Value)
+			case schema.Maximum != nil && value > *schema.Maximum:
+				validator.fail(node, pointer, "should be at most %v, got %v", *schema.Maximum, node.Value)
+			case schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum:
+				validator.fail(node, pointer, "should be greater than %v, got %v", *schema.ExclusiveMinimum, node.Value)
+			case schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum:
+				validator.fail(node, pointer, "should be less than %v, got %v", *schema.ExclusiveMaximum, node.Value)
+			}
+		}
+		return
+	}
+
+	if length := utf8.RuneCountInString(node.Value); schema.MinLength != nil && length < *schema.MinLength {
+		validator.fail(node, pointer, "should be at least %d characters long, got %d", *schema.MinLength, length)
+	} else if schema.MaxLength != nil && length > *schema.MaxLength {
+		validator.fail(node, pointer, "should be at most %d characters long, got %d", *schema.MaxLength, length)
+	}
+
+	valid := true
+	switch schema.Format {
+	case "email":
+		_, err := mail.ParseAddress(node.Value)
+		valid = err == nil
+	case "uri":
+		u, err := url.Parse(node.Value)
+		valid = err == nil && u.Scheme != ""
+	case "hostname":
+		valid = hostnameRegexp.MatchString(node.Value)
+	case "ipv4":
+		ip := net.ParseIP(node.Value)
+		valid = ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(node.Value)
+		valid = ip != nil && ip.To4() == nil
+	case "uuid":
+		valid = uuidRegexp.MatchString(node.Value)
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, node.Value)
+		valid = err == nil
+	}
+	if !valid {
+		validator.fail(node, pointer, "should be a valid %v, got %v", schema.Format, node.Value)
+	}
+}
+
+// property returns the schema of the property of schema a key of the file is decoded into, if any
+func (validator *schemaValidator) property(schema *Schema, key string) *Schema {
+	name := func(name string, property *Schema) string {
+		switch {
+		case validator.jsonFile && property.JSONName != "":
+			return property.JSONName
+		case !validator.jsonFile && property.YAMLName != "":
+			return property.YAMLName
+		}
+		return name
+	}
+
+	for propertyName, property := range schema.Properties {
+		if name(propertyName, property) == key {
+			return property
+		}
+	}
+	if validator.jsonFile {
+		for propertyName, property := range schema.Properties {
+			if strings.EqualFold(name(propertyName, property), key) {
+				return property
+			}
+		}
+	}
+	return nil
+}
+
+// yamlNodeType returns the JSON Schema type of a yaml node
+func yamlNodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+// schemaAccepts reports whether a value of nodeType matches the types of schema. Like yaml decoding,
+// strings accept any scalar, and numbers accept integers
+func schemaAccepts(schema *Schema, nodeType string) bool {
+	for _, typ := range schema.Type {
+		switch {
+		case typ == nodeType,
+			typ == "number" && nodeType == "integer",
+			typ == "string" && nodeType != "object" && nodeType != "array":
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a key for use in a JSON pointer
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
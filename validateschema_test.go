@@ -0,0 +1,140 @@
+package configor
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	type config struct {
+		Name    string `validate:"min=3"`
+		Mode    string `validate:"oneof=debug release"`
+		Timeout Duration
+		DB      struct {
+			Host string `validate:"hostname"`
+			Port int    `validate:"min=1,max=65535"`
+		}
+		Replicas []struct {
+			Email string `validate:"email"`
+		}
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`name: ab
+mode: verbose
+timeout: 30s
+db:
+  host: db.example.org
+  port: "5432"
+  hots: typo
+  Host: keys are case sensitive
+replicas:
+- email: not-an-email
+`)
+	file.Close()
+
+	var result config
+	err = New(&Config{ValidateSchema: true, Env: map[string]string{}}).Load(&result, file.Name())
+	errs, ok := err.(SchemaErrors)
+	if !ok {
+		t.Fatalf("Expected SchemaErrors, but got %v", err)
+	}
+
+	expected := []string{
+		file.Name() + ":1:7: /name: should be at least 3 characters long, got 2",
+		file.Name() + ":2:7: /mode: should be one of [debug release], got verbose",
+		file.Name() + ":6:9: /db/port: expected integer, got string",
+		file.Name() + ":7:3: /db/hots: unknown key",
+		file.Name() + ":8:3: /db/Host: unknown key",
+		file.Name() + ":10:10: /replicas/0/email: should be a valid email, got not-an-email",
+	}
+	if got := strings.Split(errs.Error(), "\n"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("\nExpected: %+v, \nGot: %+v", expected, got)
+	}
+	if result.Name != "" {
+		t.Errorf("Files should be validated before they are decoded, but got %+v", result)
+	}
+
+}
+
+func TestValidateSchemaKeys(t *testing.T) {
+	type config struct {
+		APPName string
+		DB      struct {
+			Port int    `json:"dbPort"`
+			Host string `json:"host" yaml:"db_host"`
+		}
+	}
+
+	// keys are matched the way each format is decoded
+	var tests = []struct {
+		ext      string
+		content  string
+		expected string
+	}{
+		{"json", `{"APPName": "app", "DB": {"dbPort": 1, "HOST": "localhost"}}`, ""},
+		{"json", `{"db": {"port": 1}}`, "/db/port: unknown key"},
+		{"json", `{"db": {"db_host": "localhost"}}`, "/db/db_host: unknown key"},
+		{"yml", "appname: app\ndb:\n  port: 1\n  db_host: localhost\n", ""},
+		{"yml", "APPName: app\n", "/APPName: unknown key"},
+		{"yml", "db:\n  dbPort: 1\n", "/db/dbPort: unknown key"},
+	}
+
+	for _, test := range tests {
+		file, err := ioutil.TempFile("/tmp", "configor.*."+test.ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		file.WriteString(test.content)
+		file.Close()
+
+		var result config
+		err = New(&Config{ValidateSchema: true, Env: map[string]string{}}).Load(&result, file.Name())
+		if test.expected == "" && err != nil {
+			t.Errorf("No error should happen when loading %v, but got %v", test.content, err)
+		} else if test.expected != "" && (err == nil || !strings.HasSuffix(err.Error(), test.expected)) {
+			t.Errorf("\nExpected: %+v, \nGot: %+v", test.expected, err)
+		}
+		if test.expected == "" && (result.APPName != "app" || result.DB.Port != 1 || result.DB.Host != "localhost") {
+			t.Errorf("Valid files should be decoded, instead got %+v", result)
+		}
+	}
+}
+
+func TestValidateSchemaSections(t *testing.T) {
+	type config struct {
+		Port int
+	}
+
+	file, err := ioutil.TempFile("/tmp", "configor.*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`default:
+  port: 80
+development:
+  port: eighty
+production:
+  port: 443
+`)
+	file.Close()
+
+	configor := New(&Config{Environment: "production", EnvironmentSections: true, Env: map[string]string{}})
+	if err := configor.ValidateFile(file.Name(), JSONSchema(&config{})); err != nil {
+		t.Errorf("Only the sections of active profiles should be validated, but got %v", err)
+	}
+
+	configor = New(&Config{Environment: "development", EnvironmentSections: true, Env: map[string]string{}})
+	if err := configor.ValidateFile(file.Name(), JSONSchema(&config{})); err == nil || err.Error() != file.Name()+":4:9: /development/port: expected integer, got string" {
+		t.Errorf("Expected the development section to be invalid, but got %v", err)
+	}
+}